@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type APIKeyHandler struct {
+	service *service.APIKeyService
+}
+
+func NewAPIKeyHandler(service *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// GetAll godoc
+// @Summary Get all API keys
+// @Description Mengambil semua API key (tanpa nilai key-nya) beserta waktu terakhir dipakai
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Router /api/api-keys [get]
+func (h *APIKeyHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.GetAll()
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	model.Success(w, http.StatusOK, "successfully get api keys", keys)
+}
+
+// Create godoc
+// @Summary Create API key
+// @Description Membuat API key baru untuk integrasi. Nilai key hanya ditampilkan sekali di response ini. Scope yang tersedia: catalog:read, catalog:write, checkout, report:read
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body model.CreateAPIKeyRequest true "API Key Data" SchemaExample({"name": "Accounting Sync", "scopes": ["report:read"], "expires_at": "2027-01-01T00:00:00Z"})
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Router /api/api-keys [post]
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateAPIKeyRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := utils.UserIDFromContext(r.Context())
+	resp, err := h.service.Create(userID, req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully created api key", resp)
+}
+
+// Revoke godoc
+// @Summary Revoke API key
+// @Description Mencabut API key sehingga tidak bisa dipakai lagi
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param id path int true "API Key ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Router /api/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid API Key ID")
+		return
+	}
+
+	err = h.service.Revoke(id)
+	if err != nil {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully revoked api key", nil)
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"errors"
+	"kasir-api/middleware"
 	"kasir-api/model"
 	"kasir-api/service"
 	"kasir-api/utils"
 	"net/http"
+	"strconv"
 )
 
 type AuthHandler struct {
@@ -34,6 +37,10 @@ func (hdlr *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user, err := hdlr.service.Register(req.Name, req.Email, req.Password)
+	if errors.Is(err, service.ErrRegistrationClosed) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		model.Error(w, http.StatusBadRequest, err.Error())
 		return
@@ -61,14 +68,437 @@ func (hdlr *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, token, err := hdlr.service.Login(req.Email, req.Password)
+	resp, err := hdlr.service.Login(req.Email, req.Password, utils.ClientIP(r))
+	if errors.Is(err, service.ErrTooManyAttempts) {
+		model.Error(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrAccountLocked) {
+		model.Error(w, http.StatusLocked, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrAccountInactive) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		model.Error(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
-	user.Password = ""
+	model.Success(w, http.StatusOK, "Login success", resp)
+}
+
+// Refresh godoc
+// @Summary Refresh access token
+// @Description Menukar refresh token dengan access token dan refresh token baru. Refresh token lama langsung tidak berlaku
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.RefreshRequest true "Refresh Token"
+// @Success 200 {object} model.Response
+// @Failure 401 {object} model.Response
+// @Router /api/auth/refresh [post]
+func (hdlr *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req model.RefreshRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := hdlr.service.Refresh(req.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		model.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "Token refreshed", resp)
+}
+
+// Logout godoc
+// @Summary Logout
+// @Description Me-revoke refresh token. Set "all" ke true untuk logout dari semua sesi/perangkat
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.LogoutRequest true "Refresh Token"
+// @Success 200 {object} model.Response
+// @Failure 401 {object} model.Response
+// @Router /api/auth/logout [post]
+func (hdlr *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req model.LogoutRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = hdlr.service.Logout(req.RefreshToken, req.All)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		model.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "Logout success", nil)
+}
+
+// ChangePassword godoc
+// @Summary Change password
+// @Description Mengganti password user yang sedang login. Semua refresh token milik user ikut di-revoke
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.ChangePasswordRequest true "Old and New Password"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Router /api/auth/change-password [post]
+func (hdlr *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ChangePasswordRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := utils.UserIDFromContext(r.Context())
+	err = hdlr.service.ChangePassword(userID, req.OldPassword, req.NewPassword)
+	if errors.Is(err, service.ErrWrongPassword) {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "Password changed successfully. Please login again", nil)
+}
 
-	model.Success(w, http.StatusOK, "Login success", model.LoginResponse{User: user, Token: token})
+// ForgotPassword godoc
+// @Summary Request password reset
+// @Description Mengirim token reset password ke user lewat notifier. Response selalu sukses walaupun email tidak terdaftar
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.ForgotPasswordRequest true "User Email"
+// @Success 200 {object} model.Response
+// @Router /api/auth/forgot-password [post]
+func (hdlr *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ForgotPasswordRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = hdlr.service.ForgotPassword(req.Email)
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "If the email is registered, a password reset token has been sent", nil)
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Mengganti password memakai token reset. Token hanya bisa dipakai sekali
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.ResetPasswordRequest true "Reset Token and New Password"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Router /api/auth/reset-password [post]
+func (hdlr *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPasswordRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = hdlr.service.ResetPassword(req.Token, req.NewPassword)
+	if errors.Is(err, service.ErrInvalidResetToken) {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "Password has been reset. Please login", nil)
+}
+
+// GetLoginAttempts godoc
+// @Summary Get login attempts
+// @Description Audit percobaan login (berhasil maupun gagal) untuk memantau aktivitas mencurigakan
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param email query string false "Filter by email"
+// @Param ip query string false "Filter by IP address"
+// @Param success query bool false "Filter by result"
+// @Param limit query int false "Max rows (default 100, max 500)"
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Router /api/auth/login-attempts [get]
+func (hdlr *AuthHandler) GetLoginAttempts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.LoginAttemptFilter{
+		Email: query.Get("email"),
+		IP:    query.Get("ip"),
+	}
+
+	if successStr := query.Get("success"); successStr != "" {
+		success, err := strconv.ParseBool(successStr)
+		if err != nil {
+			model.Error(w, http.StatusBadRequest, "Invalid success filter")
+			return
+		}
+		filter.Success = &success
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			model.Error(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	attempts, err := hdlr.service.GetLoginAttempts(filter)
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get login attempts", attempts)
+}
+
+// PinLogin godoc
+// @Summary Login with PIN
+// @Description Login cepat untuk kasir memakai PIN di terminal yang terdaftar. Token hanya berlaku untuk katalog dan checkout di terminal yang sama, dan request berikutnya wajib menyertakan header X-Terminal-Key
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param X-Terminal-Key header string true "Terminal Key"
+// @Param request body model.PinLoginRequest true "User ID and PIN" SchemaExample({"user_id": 2, "pin": "1234"})
+// @Success 200 {object} model.Response
+// @Failure 401 {object} model.Response
+// @Router /api/auth/pin-login [post]
+func (hdlr *AuthHandler) PinLogin(w http.ResponseWriter, r *http.Request) {
+	var req model.PinLoginRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	terminalKey := r.Header.Get(middleware.TerminalKeyHeader)
+	if terminalKey == "" {
+		model.Error(w, http.StatusUnauthorized, "missing terminal key")
+		return
+	}
+
+	resp, err := hdlr.service.PinLogin(terminalKey, req.UserID, req.PIN, utils.ClientIP(r))
+	if errors.Is(err, service.ErrTooManyAttempts) {
+		model.Error(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrAccountLocked) {
+		model.Error(w, http.StatusLocked, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrAccountInactive) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrInvalidTerminal) {
+		model.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+
+	model.Success(w, http.StatusOK, "Login success", resp)
+}
+
+// SetPin godoc
+// @Summary Set own PIN
+// @Description Mengatur PIN (4-6 digit angka) untuk PIN login di terminal kasir. Password wajib disertakan
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.SetPinRequest true "Password and New PIN" SchemaExample({"password": "#password123", "pin": "1234"})
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Router /api/auth/me/pin [put]
+func (hdlr *AuthHandler) SetPin(w http.ResponseWriter, r *http.Request) {
+	var req model.SetPinRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := utils.UserIDFromContext(r.Context())
+	err = hdlr.service.SetPin(userID, req.Password, req.PIN)
+	if errors.Is(err, service.ErrWrongPassword) {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "PIN updated successfully", nil)
+}
+
+// VerifyTwoFactor godoc
+// @Summary Verify two-factor code
+// @Description Langkah kedua login untuk user dengan 2FA aktif. Kirim challenge_token dari response login dan kode authenticator atau backup code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorVerifyRequest true "Challenge Token and Code"
+// @Success 200 {object} model.Response
+// @Failure 401 {object} model.Response
+// @Router /api/auth/2fa/verify [post]
+func (hdlr *AuthHandler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req model.TwoFactorVerifyRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := hdlr.service.VerifyTwoFactor(req.ChallengeToken, req.Code, utils.ClientIP(r))
+	if errors.Is(err, service.ErrTooManyAttempts) {
+		model.Error(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrAccountLocked) {
+		model.Error(w, http.StatusLocked, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrAccountInactive) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrInvalidChallenge) || errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		model.Error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "Login success", resp)
+}
+
+// SetupTwoFactor godoc
+// @Summary Setup two-factor authentication
+// @Description Membuat secret TOTP dan otpauth URI (untuk QR code). 2FA baru aktif setelah dikonfirmasi lewat /api/auth/2fa/enable
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Router /api/auth/2fa/setup [post]
+func (hdlr *AuthHandler) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID, _ := utils.UserIDFromContext(r.Context())
+	resp, err := hdlr.service.SetupTwoFactor(userID)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "Scan the otpauth URI with your authenticator app, then confirm with a code", resp)
+}
+
+// EnableTwoFactor godoc
+// @Summary Enable two-factor authentication
+// @Description Mengaktifkan 2FA dengan kode dari authenticator. Response berisi backup code sekali pakai yang hanya ditampilkan sekali
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorEnableRequest true "Authenticator Code" SchemaExample({"code": "123456"})
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Router /api/auth/2fa/enable [post]
+func (hdlr *AuthHandler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req model.TwoFactorEnableRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := utils.UserIDFromContext(r.Context())
+	resp, err := hdlr.service.EnableTwoFactor(userID, req.Code)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "Two-factor authentication enabled. Store the backup codes somewhere safe", resp)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Mematikan 2FA dengan password dan kode authenticator/backup code. Tidak bisa dilakukan jika role wajib 2FA
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body model.TwoFactorDisableRequest true "Password and Code"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Router /api/auth/2fa/disable [post]
+func (hdlr *AuthHandler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	var req model.TwoFactorDisableRequest
+
+	err := utils.BindAndValidate(r, &req)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := utils.UserIDFromContext(r.Context())
+	err = hdlr.service.DisableTwoFactor(userID, req.Password, req.Code)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
+	model.Success(w, http.StatusOK, "Two-factor authentication disabled", nil)
 }
@@ -2,11 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"kasir-api/model"
+	"kasir-api/repositories"
 	"kasir-api/service"
+	"kasir-api/utils"
 )
 
 type CategoryHandler struct {
@@ -50,6 +53,8 @@ func (h *CategoryHandler) HandleCategoryByID(w http.ResponseWriter, r *http.Requ
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/categories [get]
 func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	categories, err := h.service.GetAll()
@@ -60,6 +65,25 @@ func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	model.Success(w, http.StatusOK, "successfully get categories", categories)
 }
 
+// GetTree godoc
+// @Summary Get category tree
+// @Description Mengambil semua kategori sebagai pohon. Setiap node berisi jumlah produk dan nilai persediaan (stock x cost_price) untuk kategori itu sendiri (product_count, stock_value) dan termasuk semua turunannya (total_product_count, total_stock_value)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/categories/tree [get]
+func (h *CategoryHandler) GetTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetTree()
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	model.Success(w, http.StatusOK, "successfully get category tree", tree)
+}
+
 // GetByID godoc
 // @Summary Get category by ID
 // @Description Mengambil kategori berdasarkan ID
@@ -69,6 +93,8 @@ func (h *CategoryHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "Category ID"
 // @Success 200 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/categories/{id} [get]
 func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -80,7 +106,7 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	category, err := h.service.GetByID(id)
 	if err != nil {
-		model.Error(w, http.StatusNotFound, err.Error())
+		writeCategoryError(w, err)
 		return
 	}
 
@@ -89,12 +115,16 @@ func (h *CategoryHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 // Create godoc
 // @Summary Create new category
-// @Description Menambahkan kategori baru
+// @Description Menambahkan kategori baru. parent_id opsional untuk membuatnya sebagai sub-kategori
 // @Tags categories
 // @Accept json
 // @Produce json
-// @Param category body model.Category true "Category Data" SchemaExample({"name":"Category Name","description":"Category Description"})
+// @Param category body model.Category true "Category Data" SchemaExample({"name":"Kopi","description":"Minuman kopi","parent_id":1})
 // @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/categories [post]
 func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var category model.Category
@@ -105,7 +135,7 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	err := h.service.Create(&category)
 	if err != nil {
-		model.Error(w, http.StatusBadRequest, err.Error())
+		writeCategoryError(w, err)
 		return
 	}
 
@@ -114,7 +144,7 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 // Update godoc
 // @Summary Update category
-// @Description Update kategori berdasarkan ID
+// @Description Update nama dan deskripsi kategori. parent_id diabaikan, pemindahan kategori lewat PUT /api/categories/{id}/parent
 // @Tags categories
 // @Accept json
 // @Produce json
@@ -122,6 +152,8 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Param category body model.Category true "Category Data"
 // @Success 200 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/categories/{id} [put]
 func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -140,22 +172,70 @@ func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
 	category.ID = id
 	err = h.service.Update(&category)
 	if err != nil {
-		model.Error(w, http.StatusNotFound, err.Error())
+		writeCategoryError(w, err)
 		return
 	}
 
 	model.Success(w, http.StatusOK, "successfully updated category", category)
 }
 
+// Move godoc
+// @Summary Move category
+// @Description Memindahkan kategori beserta seluruh sub-kategorinya ke bawah parent_id. parent_id null menjadikannya kategori root. Kategori tidak bisa dipindah ke dirinya sendiri atau ke turunannya
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param request body model.MoveCategoryRequest true "New Parent" SchemaExample({"parent_id": 1})
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/categories/{id}/parent [put]
+func (h *CategoryHandler) Move(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Category ID")
+		return
+	}
+
+	var req model.MoveCategoryRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Move(id, req.ParentID); err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+
+	category, err := h.service.GetByID(id)
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully moved category", category)
+}
+
 // Delete godoc
 // @Summary Delete category
-// @Description Menghapus kategori berdasarkan ID
+// @Description Menghapus kategori berdasarkan ID. Mode restrict (default) menolak kategori yang masih punya produk atau sub-kategori, reassign memindahkan produk dan sub-kategorinya ke target_id lalu menghapusnya, archive mengarsipkan kategori beserta sub-kategorinya tanpa mengubah produk
 // @Tags categories
 // @Accept json
 // @Produce json
 // @Param id path int true "Category ID"
+// @Param mode query string false "Delete mode" Enums(restrict, reassign, archive)
+// @Param target_id query int false "Target category ID untuk mode reassign"
 // @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/categories/{id} [delete]
 func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -165,11 +245,43 @@ func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.Delete(id)
+	query := r.URL.Query()
+	req := model.DeleteCategoryRequest{Mode: query.Get("mode")}
+	if v := query.Get("target_id"); v != "" {
+		req.TargetID, err = strconv.Atoi(v)
+		if err != nil {
+			model.Error(w, http.StatusBadRequest, "invalid target_id")
+			return
+		}
+	}
+
+	err = h.service.Delete(id, req)
 	if err != nil {
-		model.Error(w, http.StatusNotFound, err.Error())
+		writeCategoryError(w, err)
 		return
 	}
 
+	if req.Mode == model.CategoryDeleteArchive {
+		model.Success(w, http.StatusOK, "successfully archived category", nil)
+		return
+	}
 	model.Success(w, http.StatusOK, "successfully deleted category", nil)
 }
+
+func writeCategoryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrParentCategoryNotFound),
+		errors.Is(err, repositories.ErrInvalidReassignTarget),
+		errors.Is(err, service.ErrInvalidDeleteMode):
+		model.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrCategoryNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repositories.ErrCategoryCycle),
+		errors.Is(err, repositories.ErrCategoryHasChildren),
+		errors.Is(err, repositories.ErrCategoryHasProducts),
+		errors.Is(err, repositories.ErrCategoryArchived):
+		model.Error(w, http.StatusConflict, err.Error())
+	default:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type ModifierHandler struct {
+	service *service.ModifierService
+}
+
+func NewModifierHandler(service *service.ModifierService) *ModifierHandler {
+	return &ModifierHandler{service: service}
+}
+
+// GetByProduct godoc
+// @Summary Get product modifier groups
+// @Description Mengambil semua modifier group sebuah produk beserta modifiernya
+// @Tags modifiers
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/modifier-groups [get]
+func (h *ModifierHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	groups, err := h.service.GetByProduct(productID)
+	if err != nil {
+		writeModifierError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get modifier groups", groups)
+}
+
+// Create godoc
+// @Summary Create modifier group
+// @Description Menambahkan modifier group ke produk. Group required wajib dipilih minimal min_select modifier saat checkout, max_select default 1
+// @Tags modifiers
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param group body model.ModifierGroup true "Modifier Group Data" SchemaExample({"name": "Es", "required": false, "max_select": 1, "modifiers": [{"name": "Less Ice", "price_delta": 0}, {"name": "Extra Ice", "price_delta": 2000}]})
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/modifier-groups [post]
+func (h *ModifierHandler) Create(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	var group model.ModifierGroup
+	if err := utils.BindAndValidate(r, &group); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	group.ProductID = productID
+	if err := h.service.Create(&group); err != nil {
+		writeModifierError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully added modifier group", group)
+}
+
+// Update godoc
+// @Summary Update modifier group
+// @Description Mengganti pengaturan group dan daftar modifiernya. Modifier dengan id diperbarui, tanpa id ditambahkan, dan yang tidak disebut dihapus
+// @Tags modifiers
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param groupId path int true "Modifier Group ID"
+// @Param group body model.ModifierGroup true "Modifier Group Data"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/modifier-groups/{groupId} [put]
+func (h *ModifierHandler) Update(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+	groupID, err := strconv.Atoi(r.PathValue("groupId"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Modifier Group ID")
+		return
+	}
+
+	var group model.ModifierGroup
+	if err := utils.BindAndValidate(r, &group); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	group.ID = groupID
+	group.ProductID = productID
+	if err := h.service.Update(&group); err != nil {
+		writeModifierError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated modifier group", group)
+}
+
+// Delete godoc
+// @Summary Delete modifier group
+// @Description Menghapus modifier group beserta modifiernya. Transaksi lama tetap menyimpan snapshot modifier yang dipilih
+// @Tags modifiers
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param groupId path int true "Modifier Group ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/modifier-groups/{groupId} [delete]
+func (h *ModifierHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+	groupID, err := strconv.Atoi(r.PathValue("groupId"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Modifier Group ID")
+		return
+	}
+
+	if err := h.service.Delete(productID, groupID); err != nil {
+		writeModifierError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully deleted modifier group", nil)
+}
+
+func writeModifierError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidModifierGroup), errors.Is(err, repositories.ErrModifierNotFound):
+		model.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrProductNotFound), errors.Is(err, repositories.ErrModifierGroupNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repositories.ErrDuplicateModifier):
+		model.Error(w, http.StatusConflict, err.Error())
+	default:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
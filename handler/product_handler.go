@@ -2,10 +2,15 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"kasir-api/model"
+	"kasir-api/repositories"
 	"kasir-api/service"
 )
 
@@ -24,16 +29,124 @@ func NewProductHandler(service *service.ProductService) *ProductHandler {
 // @Accept json
 // @Produce json
 // @Param name query string false "Product Name"
+// @Param category_id query int false "Category ID (termasuk semua sub-kategorinya)"
+// @Param min_price query int false "Minimum Price"
+// @Param max_price query int false "Maximum Price"
+// @Param in_stock query bool false "Only products with stock > 0"
+// @Param sort query string false "Sort by" Enums(id, name, price, stock)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Offset"
 // @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/products [get]
 func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
-	products, err := h.service.GetAll(name)
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	products, pagination, err := h.service.GetAll(filter)
 	if err != nil {
 		model.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	model.Success(w, http.StatusOK, "successfully get products", products)
+	model.SuccessWithMeta(w, http.StatusOK, "successfully get products", products, pagination)
+}
+
+// GetArchived godoc
+// @Summary Get archived products
+// @Description Mengambil produk yang sudah diarsipkan (soft delete) dengan filter, sorting, dan paginasi yang sama seperti list produk
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param name query string false "Product Name"
+// @Param category_id query int false "Category ID (termasuk semua sub-kategorinya)"
+// @Param sort query string false "Sort by" Enums(id, name, price, stock)
+// @Param order query string false "Sort order" Enums(asc, desc)
+// @Param limit query int false "Page size (default 20, max 100)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/archived [get]
+func (h *ProductHandler) GetArchived(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Archived = true
+
+	products, pagination, err := h.service.GetAll(filter)
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	model.SuccessWithMeta(w, http.StatusOK, "successfully get archived products", products, pagination)
+}
+
+// parseProductFilter membaca query string filter, sorting, dan paginasi produk
+func parseProductFilter(r *http.Request) (model.ProductFilter, error) {
+	query := r.URL.Query()
+	filter := model.ProductFilter{
+		Name:  query.Get("name"),
+		Sort:  query.Get("sort"),
+		Order: query.Get("order"),
+	}
+
+	if filter.Sort != "" && !slices.Contains([]string{"id", "name", "price", "stock"}, filter.Sort) {
+		return filter, fmt.Errorf("invalid sort %q, must be one of: id, name, price, stock", filter.Sort)
+	}
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		return filter, fmt.Errorf("invalid order %q, must be asc or desc", filter.Order)
+	}
+
+	intParams := []struct {
+		name string
+		dst  *int
+	}{
+		{"category_id", &filter.CategoryID},
+		{"limit", &filter.Limit},
+		{"offset", &filter.Offset},
+	}
+	for _, p := range intParams {
+		if v := query.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", p.name)
+			}
+			*p.dst = n
+		}
+	}
+
+	if v := query.Get("min_price"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid min_price")
+		}
+		filter.MinPrice = &n
+	}
+	if v := query.Get("max_price"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, errors.New("invalid max_price")
+		}
+		filter.MaxPrice = &n
+	}
+
+	if v := query.Get("in_stock"); v != "" {
+		inStock, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, errors.New("invalid in_stock")
+		}
+		filter.InStock = inStock
+	}
+
+	return filter, nil
 }
 
 // GetByID godoc
@@ -45,6 +158,8 @@ func (h *ProductHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "Product ID"
 // @Success 200 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/products/{id} [get]
 func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -64,14 +179,133 @@ func (h *ProductHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	model.Success(w, http.StatusOK, "successfully get product", product)
 }
 
+// GetLowStock godoc
+// @Summary Get low-stock products
+// @Description Mengambil produk yang stoknya sudah mencapai atau di bawah min_stock (reorder point), paling kritis lebih dulu
+// @Tags products
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/low-stock [get]
+func (h *ProductHandler) GetLowStock(w http.ResponseWriter, r *http.Request) {
+	products, err := h.service.GetLowStock()
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get low-stock products", products)
+}
+
+// GetExpiring godoc
+// @Summary Get expiring product lots
+// @Description Mengambil lot yang akan kedaluwarsa dalam rentang within (mis. 7d, default 7d), termasuk yang sudah kedaluwarsa, paling dekat lebih dulu
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param within query string false "Rentang hari ke depan, contoh 7d atau 30d"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/expiring [get]
+func (h *ProductHandler) GetExpiring(w http.ResponseWriter, r *http.Request) {
+	days, err := parseWithinDays(r.URL.Query().Get("within"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	lots, err := h.service.GetExpiring(days)
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get expiring lots", lots)
+}
+
+// parseWithinDays membaca rentang hari seperti "7d" (atau "7"), default 7 hari
+func parseWithinDays(within string) (int, error) {
+	if within == "" {
+		return 7, nil
+	}
+
+	days, err := strconv.Atoi(strings.TrimSuffix(within, "d"))
+	if err != nil || days < 0 || days > 365 {
+		return 0, fmt.Errorf("invalid within %q, use number of days like 7d (max 365d)", within)
+	}
+	return days, nil
+}
+
+// GetLots godoc
+// @Summary Get product lots
+// @Description Mengambil lot (batch) produk track_expiry yang masih punya sisa stok, urut FEFO
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/lots [get]
+func (h *ProductHandler) GetLots(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	lots, err := h.service.GetLots(id)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get product lots", lots)
+}
+
+// GetByBarcode godoc
+// @Summary Get product by barcode
+// @Description Mencari produk dari hasil scan barcode (EAN-8, UPC-A, atau EAN-13)
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param code path string true "Barcode"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/barcode/{code} [get]
+func (h *ProductHandler) GetByBarcode(w http.ResponseWriter, r *http.Request) {
+	product, err := h.service.GetByBarcode(r.PathValue("code"))
+	if err != nil {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get product", product)
+}
+
 // Create godoc
 // @Summary Create new product
-// @Description Menambahkan produk baru
+// @Description Menambahkan produk baru. Stok awal dicatat sebagai adjustment di riwayat stok. Produk track_expiry harus dibuat dengan stok 0, lalu stok masuk lewat restock dengan expiry_date
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param product body model.Product true "Product Data" SchemaExample({"name":"Product Name","price":10000,"stock":5})
+// @Param product body model.Product true "Product Data" SchemaExample({"name":"Product Name","price":10000,"stock":5,"cost_price":7000,"min_stock":2,"sku":"SKU-001","barcodes":["8991002101012"]})
 // @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/products [post]
 func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var product model.Product
@@ -80,7 +314,11 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.service.Create(&product)
+	err := h.service.Create(&product, currentUserID(r))
+	if errors.Is(err, repositories.ErrDuplicateProductCode) {
+		model.Error(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		model.Error(w, http.StatusBadRequest, err.Error())
 		return
@@ -91,14 +329,18 @@ func (h *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 // Update godoc
 // @Summary Update product
-// @Description Update produk berdasarkan ID
+// @Description Update produk berdasarkan ID. Stok dan HPP tidak bisa diubah di sini, perubahan stok harus lewat /api/products/{id}/stock-movements. min_stock, track_expiry, base_unit, dan barcodes yang tidak dikirim tidak diubah
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
-// @Param product body model.Product true "Product Data"
+// @Param product body model.UpdateProductRequest true "Product Data"
 // @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/products/{id} [put]
 func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -108,15 +350,23 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var product model.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
+	var req model.UpdateProductRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		model.Error(w, http.StatusBadRequest, "Invalid Request")
 		return
 	}
 
-	product.ID = id
-	err = h.service.Update(&product)
-	if err != nil {
+	product, err := h.service.Update(id, req)
+	switch {
+	case errors.Is(err, service.ErrInvalidBarcode), errors.Is(err, service.ErrInvalidMinStock),
+		errors.Is(err, repositories.ErrCategoryNotFound), errors.Is(err, repositories.ErrCategoryArchived):
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, repositories.ErrDuplicateProductCode), errors.Is(err, repositories.ErrTrackExpiryChange),
+		errors.Is(err, repositories.ErrBaseUnitChange):
+		model.Error(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
 		model.Error(w, http.StatusNotFound, err.Error())
 		return
 	}
@@ -126,13 +376,16 @@ func (h *ProductHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 // Delete godoc
 // @Summary Delete product
-// @Description Menghapus produk berdasarkan ID
+// @Description Mengarsipkan produk (soft delete). Produk hilang dari katalog dan checkout, tapi tetap muncul di riwayat transaksi dan laporan, dan bisa di-restore
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
 // @Success 200 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/products/{id} [delete]
 func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -143,10 +396,59 @@ func (h *ProductHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.service.Delete(id)
+	switch {
+	case errors.Is(err, repositories.ErrProductNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, repositories.ErrProductArchived):
+		model.Error(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully archived product", nil)
+}
+
+// Restore godoc
+// @Summary Restore archived product
+// @Description Mengaktifkan kembali produk yang sudah diarsipkan sehingga muncul lagi di katalog dan bisa dijual
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/restore [post]
+func (h *ProductHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	err = h.service.Restore(id)
+	switch {
+	case errors.Is(err, repositories.ErrProductNotFound):
 		model.Error(w, http.StatusNotFound, err.Error())
 		return
+	case errors.Is(err, repositories.ErrProductNotArchived):
+		model.Error(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	product, err := h.service.GetByIDWithCategory(id)
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	model.Success(w, http.StatusOK, "successfully deleted product", nil)
+	model.Success(w, http.StatusOK, "successfully restored product", product)
 }
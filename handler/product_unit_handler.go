@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type ProductUnitHandler struct {
+	service *service.ProductUnitService
+}
+
+func NewProductUnitHandler(service *service.ProductUnitService) *ProductUnitHandler {
+	return &ProductUnitHandler{service: service}
+}
+
+// GetByProduct godoc
+// @Summary Get product units
+// @Description Mengambil semua satuan alternatif sebuah produk beserta faktor konversinya ke satuan dasar
+// @Tags product-units
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/units [get]
+func (h *ProductUnitHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	units, err := h.service.GetByProduct(productID)
+	if err != nil {
+		writeUnitError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get units", units)
+}
+
+// Create godoc
+// @Summary Create product unit
+// @Description Menambahkan satuan alternatif ke produk. Factor adalah jumlah satuan dasar dalam satu satuan ini; price kosong berarti harga produk dikali factor
+// @Tags product-units
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param unit body model.ProductUnit true "Unit Data" SchemaExample({"name": "karton", "factor": 24, "price": 110000})
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/units [post]
+func (h *ProductUnitHandler) Create(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	var unit model.ProductUnit
+	if err := utils.BindAndValidate(r, &unit); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	unit.ProductID = productID
+	if err := h.service.Create(&unit); err != nil {
+		writeUnitError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully added unit", unit)
+}
+
+// Update godoc
+// @Summary Update product unit
+// @Description Update nama, faktor konversi, dan harga satuan. Transaksi dan PO lama tidak terpengaruh karena satuannya di-snapshot
+// @Tags product-units
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param unitId path int true "Unit ID"
+// @Param unit body model.ProductUnit true "Unit Data"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/units/{unitId} [put]
+func (h *ProductUnitHandler) Update(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+	unitID, err := strconv.Atoi(r.PathValue("unitId"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Unit ID")
+		return
+	}
+
+	var unit model.ProductUnit
+	if err := utils.BindAndValidate(r, &unit); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	unit.ID = unitID
+	unit.ProductID = productID
+	if err := h.service.Update(&unit); err != nil {
+		writeUnitError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated unit", unit)
+}
+
+// Delete godoc
+// @Summary Delete product unit
+// @Description Menghapus satuan alternatif produk
+// @Tags product-units
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param unitId path int true "Unit ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/units/{unitId} [delete]
+func (h *ProductUnitHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+	unitID, err := strconv.Atoi(r.PathValue("unitId"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Unit ID")
+		return
+	}
+
+	if err := h.service.Delete(productID, unitID); err != nil {
+		writeUnitError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully deleted unit", nil)
+}
+
+func writeUnitError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrProductNotFound), errors.Is(err, repositories.ErrUnitNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repositories.ErrDuplicateUnit):
+		model.Error(w, http.StatusConflict, err.Error())
+	default:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
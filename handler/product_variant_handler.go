@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type ProductVariantHandler struct {
+	service *service.ProductVariantService
+}
+
+func NewProductVariantHandler(service *service.ProductVariantService) *ProductVariantHandler {
+	return &ProductVariantHandler{service: service}
+}
+
+// GetByProduct godoc
+// @Summary Get product variants
+// @Description Mengambil semua varian sebuah produk
+// @Tags product-variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/variants [get]
+func (h *ProductVariantHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	variants, err := h.service.GetByProduct(productID)
+	if err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get variants", variants)
+}
+
+// Create godoc
+// @Summary Create product variant
+// @Description Menambahkan varian ke produk. Stok awal dicatat sebagai adjustment dan ikut menambah stok produk induk. Varian pertama hanya bisa dibuat jika stok produk induk 0
+// @Tags product-variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variant body model.ProductVariant true "Variant Data" SchemaExample({"name": "L / Hitam", "options": {"size": "L", "color": "Hitam"}, "sku": "KAOS-L-HTM", "price": 65000, "stock": 10})
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/variants [post]
+func (h *ProductVariantHandler) Create(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	var variant model.ProductVariant
+	if err := utils.BindAndValidate(r, &variant); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	variant.ProductID = productID
+	if err := h.service.Create(&variant, currentUserID(r)); err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully added variant", variant)
+}
+
+// Update godoc
+// @Summary Update product variant
+// @Description Update nama, opsi, SKU, dan harga varian. Field stock diabaikan, perubahan stok harus lewat stock movements
+// @Tags product-variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Param variant body model.ProductVariant true "Variant Data"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/variants/{variantId} [put]
+func (h *ProductVariantHandler) Update(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+	variantID, err := strconv.Atoi(r.PathValue("variantId"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Variant ID")
+		return
+	}
+
+	var variant model.ProductVariant
+	if err := utils.BindAndValidate(r, &variant); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	variant.ID = variantID
+	variant.ProductID = productID
+	if err := h.service.Update(&variant); err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated variant", variant)
+}
+
+// Delete godoc
+// @Summary Delete product variant
+// @Description Menghapus varian yang belum pernah punya stok maupun penjualan
+// @Tags product-variants
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/variants/{variantId} [delete]
+func (h *ProductVariantHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+	variantID, err := strconv.Atoi(r.PathValue("variantId"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Variant ID")
+		return
+	}
+
+	if err := h.service.Delete(productID, variantID); err != nil {
+		writeVariantError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully deleted variant", nil)
+}
+
+func writeVariantError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrExpiryRequired):
+		model.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrProductNotFound), errors.Is(err, repositories.ErrVariantNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repositories.ErrDuplicateVariant),
+		errors.Is(err, repositories.ErrProductHasStock),
+		errors.Is(err, repositories.ErrVariantInUse),
+		errors.Is(err, repositories.ErrCompositeConflict),
+		errors.Is(err, repositories.ErrInsufficientStock):
+		model.Error(w, http.StatusConflict, err.Error())
+	default:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type PurchaseOrderHandler struct {
+	service *service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service *service.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{service: service}
+}
+
+var purchaseOrderStatuses = []string{
+	model.PurchaseOrderDraft, model.PurchaseOrderSent, model.PurchaseOrderPartiallyReceived,
+	model.PurchaseOrderReceived, model.PurchaseOrderCancelled,
+}
+
+// GetAll godoc
+// @Summary Get all purchase orders
+// @Description Mengambil semua purchase order, terbaru lebih dulu
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param status query string false "Status" Enums(draft, sent, partially_received, received, cancelled)
+// @Param supplier_id query int false "Supplier ID"
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/purchase-orders [get]
+func (h *PurchaseOrderHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := model.PurchaseOrderFilter{Status: query.Get("status")}
+
+	if filter.Status != "" && !slices.Contains(purchaseOrderStatuses, filter.Status) {
+		model.Error(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+	if v := query.Get("supplier_id"); v != "" {
+		supplierID, err := strconv.Atoi(v)
+		if err != nil {
+			model.Error(w, http.StatusBadRequest, "invalid supplier_id")
+			return
+		}
+		filter.SupplierID = supplierID
+	}
+
+	orders, err := h.service.GetAll(filter)
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	model.Success(w, http.StatusOK, "successfully get purchase orders", orders)
+}
+
+// Create godoc
+// @Summary Create purchase order
+// @Description Membuat purchase order baru berstatus draft
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param order body model.CreatePurchaseOrderRequest true "Purchase Order" SchemaExample({"supplier_id": 1, "note": "Restock mingguan", "items": [{"product_id": 1, "quantity": 24, "unit_cost": 3500}]})
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/purchase-orders [post]
+func (h *PurchaseOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreatePurchaseOrderRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order, err := h.service.Create(req, currentUserID(r))
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully created purchase order", order)
+}
+
+// GetByID godoc
+// @Summary Get purchase order by ID
+// @Description Mengambil purchase order beserta item dan jumlah yang sudah diterima
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase Order ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Purchase Order ID")
+		return
+	}
+
+	order, err := h.service.GetByID(id)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get purchase order", order)
+}
+
+// Send godoc
+// @Summary Send purchase order
+// @Description Menandai purchase order draft sudah dikirim ke supplier
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase Order ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/purchase-orders/{id}/send [post]
+func (h *PurchaseOrderHandler) Send(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Purchase Order ID")
+		return
+	}
+
+	order, err := h.service.Send(id)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully sent purchase order", order)
+}
+
+// Cancel godoc
+// @Summary Cancel purchase order
+// @Description Membatalkan purchase order yang belum selesai diterima
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase Order ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/purchase-orders/{id}/cancel [post]
+func (h *PurchaseOrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Purchase Order ID")
+		return
+	}
+
+	order, err := h.service.Cancel(id)
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully cancelled purchase order", order)
+}
+
+// Receive godoc
+// @Summary Receive goods
+// @Description Mencatat penerimaan barang untuk purchase order yang sudah dikirim. Stok produk bertambah dan status PO menjadi partially_received atau received. Produk track_expiry wajib menyertakan expiry_date per item
+// @Tags purchase-orders
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase Order ID"
+// @Param receipt body model.ReceivePurchaseOrderRequest true "Goods Receipt" SchemaExample({"reference": "SJ-0012", "items": [{"product_id": 1, "quantity": 12}]})
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/purchase-orders/{id}/receive [post]
+func (h *PurchaseOrderHandler) Receive(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Purchase Order ID")
+		return
+	}
+
+	var req model.ReceivePurchaseOrderRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	order, err := h.service.Receive(id, req, currentUserID(r))
+	if err != nil {
+		writePurchaseOrderError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully received goods", order)
+}
+
+func writePurchaseOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrPurchaseOrderNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrDuplicateOrderItem),
+		errors.Is(err, repositories.ErrSupplierNotFound),
+		errors.Is(err, repositories.ErrProductNotFound),
+		errors.Is(err, repositories.ErrVariantRequired),
+		errors.Is(err, repositories.ErrVariantNotFound),
+		errors.Is(err, repositories.ErrExpiryRequired),
+		errors.Is(err, repositories.ErrUnitNotFound),
+		errors.Is(err, repositories.ErrItemNotInPurchaseOrder),
+		errors.Is(err, repositories.ErrOverReceive):
+		model.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrPurchaseOrderStatus), errors.Is(err, repositories.ErrCompositeConflict),
+		errors.Is(err, repositories.ErrProductNotOrderable):
+		model.Error(w, http.StatusConflict, err.Error())
+	default:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type RecipeHandler struct {
+	service *service.RecipeService
+}
+
+func NewRecipeHandler(service *service.RecipeService) *RecipeHandler {
+	return &RecipeHandler{service: service}
+}
+
+// GetByProduct godoc
+// @Summary Get product recipe
+// @Description Mengambil resep (bill of materials) produk komposit beserta HPP per unit dan jumlah unit yang masih bisa dibuat dari stok bahan
+// @Tags recipes
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/recipe [get]
+func (h *RecipeHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	recipe, err := h.service.GetByProduct(productID)
+	if err != nil {
+		writeRecipeError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get recipe", recipe)
+}
+
+// Set godoc
+// @Summary Set product recipe
+// @Description Mengganti seluruh resep produk. Menjual produk komposit mengurangi stok bahan-bahannya. Daftar bahan kosong menghapus resep
+// @Tags recipes
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param recipe body model.SetRecipeRequest true "Recipe Data" SchemaExample({"ingredients": [{"ingredient_id": 10, "quantity": 150}, {"ingredient_id": 11, "quantity": 1}]})
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/recipe [put]
+func (h *RecipeHandler) Set(w http.ResponseWriter, r *http.Request) {
+	productID, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	var req model.SetRecipeRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recipe, err := h.service.Set(productID, req)
+	if err != nil {
+		writeRecipeError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated recipe", recipe)
+}
+
+func writeRecipeError(w http.ResponseWriter, err error) {
+	switch {
+	// ErrProductNotFound tanpa wrapping berarti produk di path yang tidak ada,
+	// versi wrapped-nya berarti salah satu bahan yang tidak ada
+	case err == repositories.ErrProductNotFound:
+		model.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repositories.ErrProductNotFound),
+		errors.Is(err, repositories.ErrInvalidIngredient),
+		errors.Is(err, service.ErrDuplicateIngredient):
+		model.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrCompositeConflict):
+		model.Error(w, http.StatusConflict, err.Error())
+	default:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type StockHandler struct {
+	service *service.StockService
+}
+
+func NewStockHandler(service *service.StockService) *StockHandler {
+	return &StockHandler{service: service}
+}
+
+// GetByProduct godoc
+// @Summary Get product stock movements
+// @Description Mengambil riwayat pergerakan stok sebuah produk, terbaru lebih dulu
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param limit query int false "Page size (default 50, max 200)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/stock-movements [get]
+func (h *StockHandler) GetByProduct(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	movements, pagination, err := h.service.GetByProduct(id, limit, offset)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.SuccessWithMeta(w, http.StatusOK, "successfully get stock movements", movements, pagination)
+}
+
+// Create godoc
+// @Summary Record stock movement
+// @Description Mencatat pergerakan stok manual (restock, adjustment, return, waste) dan memperbarui stok produk. Untuk produk bervarian, variant_id wajib diisi. Untuk produk track_expiry, barang masuk wajib membawa expiry_date dan barang keluar mengurangi lot FEFO
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param movement body model.CreateStockMovementRequest true "Stock Movement" SchemaExample({"type": "restock", "quantity": 24, "reason": "kiriman supplier", "reference": "INV-001"})
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/products/{id}/stock-movements [post]
+func (h *StockHandler) Create(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Product ID")
+		return
+	}
+
+	var req model.CreateStockMovementRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	movement, err := h.service.CreateMovement(id, req, currentUserID(r))
+	switch {
+	case errors.Is(err, service.ErrInvalidMovement),
+		errors.Is(err, repositories.ErrVariantRequired),
+		errors.Is(err, repositories.ErrExpiryRequired),
+		errors.Is(err, repositories.ErrUnitNotFound),
+		errors.Is(err, repositories.ErrInvalidQuantity):
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, repositories.ErrProductNotFound), errors.Is(err, repositories.ErrVariantNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, repositories.ErrInsufficientStock), errors.Is(err, repositories.ErrCompositeConflict):
+		model.Error(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully recorded stock movement", movement)
+}
+
+// currentUserID mengembalikan ID user yang sedang login untuk dicatat di data
+// audit, atau nil jika request memakai API key
+func currentUserID(r *http.Request) *int {
+	userID, ok := utils.UserIDFromContext(r.Context())
+	if !ok || userID == 0 {
+		return nil
+	}
+	return &userID
+}
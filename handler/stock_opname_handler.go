@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type StockOpnameHandler struct {
+	service *service.StockOpnameService
+}
+
+func NewStockOpnameHandler(service *service.StockOpnameService) *StockOpnameHandler {
+	return &StockOpnameHandler{service: service}
+}
+
+// GetAll godoc
+// @Summary Get all stock opnames
+// @Description Mengambil semua sesi stock opname, terbaru lebih dulu
+// @Tags stock-opname
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/stock-opnames [get]
+func (h *StockOpnameHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	opnames, err := h.service.GetAll()
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	model.Success(w, http.StatusOK, "successfully get stock opnames", opnames)
+}
+
+// Start godoc
+// @Summary Start stock opname
+// @Description Memulai sesi hitung fisik stok. Hanya boleh ada satu sesi open dalam satu waktu
+// @Tags stock-opname
+// @Accept json
+// @Produce json
+// @Param opname body model.StartStockOpnameRequest true "Stock Opname" SchemaExample({"note": "Opname akhir bulan"})
+// @Success 201 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/stock-opnames [post]
+func (h *StockOpnameHandler) Start(w http.ResponseWriter, r *http.Request) {
+	var req model.StartStockOpnameRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opname, err := h.service.Start(req.Note, currentUserID(r))
+	if errors.Is(err, repositories.ErrOpnameAlreadyOpen) {
+		model.Error(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully started stock opname", opname)
+}
+
+// GetByID godoc
+// @Summary Get stock opname
+// @Description Mengambil sesi stock opname beserta hasil hitungan, selisih terhadap stok sistem, dan nilai shrinkage
+// @Tags stock-opname
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock Opname ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/stock-opnames/{id} [get]
+func (h *StockOpnameHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Stock Opname ID")
+		return
+	}
+
+	detail, err := h.service.GetDetail(id)
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get stock opname", detail)
+}
+
+// SubmitCounts godoc
+// @Summary Submit counted quantities
+// @Description Menyimpan hasil hitungan fisik, bisa dikirim dalam beberapa batch. Produk yang sudah dihitung akan ditimpa. Hitungan produk track_expiry yang melebihi stok sistem wajib membawa expiry_date
+// @Tags stock-opname
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock Opname ID"
+// @Param counts body model.SubmitStockCountRequest true "Counted Quantities" SchemaExample({"items": [{"product_id": 1, "counted_qty": 10}]})
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/stock-opnames/{id}/items [post]
+func (h *StockOpnameHandler) SubmitCounts(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Stock Opname ID")
+		return
+	}
+
+	var req model.SubmitStockCountRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	detail, err := h.service.SubmitCounts(id, req.Items, currentUserID(r))
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully submitted stock counts", detail)
+}
+
+// Finalize godoc
+// @Summary Finalize stock opname
+// @Description Memposting selisih hitungan sebagai adjustment stok secara atomik dan menutup sesi
+// @Tags stock-opname
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock Opname ID"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/stock-opnames/{id}/finalize [post]
+func (h *StockOpnameHandler) Finalize(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Stock Opname ID")
+		return
+	}
+
+	detail, err := h.service.Finalize(id, currentUserID(r))
+	if err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully finalized stock opname", detail)
+}
+
+// Cancel godoc
+// @Summary Cancel stock opname
+// @Description Membatalkan sesi stock opname tanpa mengubah stok
+// @Tags stock-opname
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock Opname ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/stock-opnames/{id}/cancel [post]
+func (h *StockOpnameHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Stock Opname ID")
+		return
+	}
+
+	if err := h.service.Cancel(id, currentUserID(r)); err != nil {
+		writeOpnameError(w, err)
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully cancelled stock opname", nil)
+}
+
+func writeOpnameError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrOpnameNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, repositories.ErrProductNotFound),
+		errors.Is(err, repositories.ErrVariantRequired),
+		errors.Is(err, repositories.ErrVariantNotFound),
+		errors.Is(err, repositories.ErrExpiryRequired):
+		model.Error(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, repositories.ErrOpnameClosed),
+		errors.Is(err, repositories.ErrInsufficientStock),
+		errors.Is(err, repositories.ErrCompositeConflict):
+		model.Error(w, http.StatusConflict, err.Error())
+	default:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+	}
+}
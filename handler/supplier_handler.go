@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type SupplierHandler struct {
+	service *service.SupplierService
+}
+
+func NewSupplierHandler(service *service.SupplierService) *SupplierHandler {
+	return &SupplierHandler{service: service}
+}
+
+// GetAll godoc
+// @Summary Get all suppliers
+// @Description Mengambil semua supplier
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/suppliers [get]
+func (h *SupplierHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	suppliers, err := h.service.GetAll()
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	model.Success(w, http.StatusOK, "successfully get suppliers", suppliers)
+}
+
+// Create godoc
+// @Summary Create supplier
+// @Description Menambahkan supplier baru
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param supplier body model.Supplier true "Supplier Data" SchemaExample({"name": "PT Sumber Makmur", "contact_name": "Budi", "phone": "08123456789", "payment_terms": "NET 30"})
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/suppliers [post]
+func (h *SupplierHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var supplier model.Supplier
+	if err := utils.BindAndValidate(r, &supplier); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.service.Create(&supplier); err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully added supplier", supplier)
+}
+
+// GetByID godoc
+// @Summary Get supplier by ID
+// @Description Mengambil supplier berdasarkan ID
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/suppliers/{id} [get]
+func (h *SupplierHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Supplier ID")
+		return
+	}
+
+	supplier, err := h.service.GetByID(id)
+	if errors.Is(err, repositories.ErrSupplierNotFound) {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get supplier", supplier)
+}
+
+// Update godoc
+// @Summary Update supplier
+// @Description Update supplier berdasarkan ID
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Param supplier body model.Supplier true "Supplier Data"
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/suppliers/{id} [put]
+func (h *SupplierHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Supplier ID")
+		return
+	}
+
+	var supplier model.Supplier
+	if err := utils.BindAndValidate(r, &supplier); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	supplier.ID = id
+	err = h.service.Update(&supplier)
+	if errors.Is(err, repositories.ErrSupplierNotFound) {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated supplier", supplier)
+}
+
+// Delete godoc
+// @Summary Delete supplier
+// @Description Menghapus supplier. Supplier yang sudah punya purchase order tidak bisa dihapus
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/suppliers/{id} [delete]
+func (h *SupplierHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Supplier ID")
+		return
+	}
+
+	err = h.service.Delete(id)
+	switch {
+	case errors.Is(err, repositories.ErrSupplierNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, repositories.ErrSupplierInUse):
+		model.Error(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully deleted supplier", nil)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"kasir-api/model"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+type TerminalHandler struct {
+	service *service.TerminalService
+}
+
+func NewTerminalHandler(service *service.TerminalService) *TerminalHandler {
+	return &TerminalHandler{service: service}
+}
+
+// GetAll godoc
+// @Summary Get all terminals
+// @Description Mengambil semua terminal kasir yang terdaftar
+// @Tags terminals
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Router /api/terminals [get]
+func (h *TerminalHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	terminals, err := h.service.GetAll()
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	model.Success(w, http.StatusOK, "successfully get terminals", terminals)
+}
+
+// Create godoc
+// @Summary Register terminal
+// @Description Mendaftarkan terminal kasir baru. Key terminal hanya ditampilkan sekali di response ini
+// @Tags terminals
+// @Accept json
+// @Produce json
+// @Param terminal body model.CreateTerminalRequest true "Terminal Data" SchemaExample({"name": "Kasir Depan"})
+// @Success 201 {object} model.Response
+// @Security BearerAuth
+// @Router /api/terminals [post]
+func (h *TerminalHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateTerminalRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resp, err := h.service.Create(req.Name)
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully registered terminal", resp)
+}
+
+// Deactivate godoc
+// @Summary Deactivate terminal
+// @Description Menonaktifkan terminal sehingga tidak bisa dipakai untuk PIN login lagi
+// @Tags terminals
+// @Accept json
+// @Produce json
+// @Param id path int true "Terminal ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Router /api/terminals/{id}/deactivate [post]
+func (h *TerminalHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, false)
+}
+
+// Activate godoc
+// @Summary Activate terminal
+// @Description Mengaktifkan kembali terminal yang sebelumnya dinonaktifkan
+// @Tags terminals
+// @Accept json
+// @Produce json
+// @Param id path int true "Terminal ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Router /api/terminals/{id}/activate [post]
+func (h *TerminalHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	h.setActive(w, r, true)
+}
+
+func (h *TerminalHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Terminal ID")
+		return
+	}
+
+	err = h.service.SetActive(id, active)
+	if err != nil {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated terminal", nil)
+}
@@ -2,9 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"kasir-api/model"
+	"kasir-api/repositories"
 	"kasir-api/service"
 )
 
@@ -27,12 +30,17 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 
 // Checkout godoc
 // @Summary Checkout products
-// @Description Membuat transaksi baru dan mengurangi stok produk
+// @Description Membuat transaksi baru dan mengurangi stok produk. Setiap item bisa membawa modifier_ids; harga modifier ditambahkan ke harga satuan
 // @Tags transactions
 // @Accept json
 // @Produce json
 // @Param request body model.CheckoutRequest true "Checkout Request"
 // @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Failure 409 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/checkout [post]
 func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	var req model.CheckoutRequest
@@ -42,8 +50,20 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items)
-	if err != nil {
+	transaction, err := h.service.Checkout(req.Items, currentUserID(r))
+	switch {
+	case errors.Is(err, repositories.ErrVariantRequired), errors.Is(err, repositories.ErrInvalidModifierSelection),
+		errors.Is(err, repositories.ErrUnitNotFound), errors.Is(err, repositories.ErrInvalidQuantity):
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, repositories.ErrProductNotFound), errors.Is(err, repositories.ErrVariantNotFound):
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, repositories.ErrInsufficientStock), errors.Is(err, repositories.ErrExpiredStock),
+		errors.Is(err, repositories.ErrProductNotForSale):
+		model.Error(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
 		model.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
@@ -51,6 +71,38 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	model.Success(w, http.StatusOK, "checkout success", transaction)
 }
 
+// GetByID godoc
+// @Summary Get transaction by ID
+// @Description Mengambil transaksi beserta detail, varian, dan modifier yang dipilih, untuk cetak ulang struk
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/transactions/{id} [get]
+func (h *TransactionHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid Transaction ID")
+		return
+	}
+
+	transaction, err := h.service.GetByID(id)
+	if errors.Is(err, repositories.ErrTransactionNotFound) {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get transaction", transaction)
+}
+
 // GetTodaySummary godoc
 // @Summary Get today's sales summary
 // @Description Mengambil ringkasan penjualan hari ini
@@ -58,6 +110,8 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/report/hari-ini [get]
 func (h *TransactionHandler) GetTodaySummary(w http.ResponseWriter, r *http.Request) {
 	summary, err := h.service.GetTodaySummary()
@@ -78,6 +132,8 @@ func (h *TransactionHandler) GetTodaySummary(w http.ResponseWriter, r *http.Requ
 // @Param start_date query string false "Start Date (YYYY-MM-DD)"
 // @Param end_date query string false "End Date (YYYY-MM-DD)"
 // @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /api/report [get]
 func (h *TransactionHandler) GetSummaryByRange(w http.ResponseWriter, r *http.Request) {
 	startDate := r.URL.Query().Get("start_date")
@@ -91,3 +147,28 @@ func (h *TransactionHandler) GetSummaryByRange(w http.ResponseWriter, r *http.Re
 
 	model.Success(w, http.StatusOK, "successfully get summary", summary)
 }
+
+// GetProfitReport godoc
+// @Summary Get gross profit report
+// @Description Mengambil laporan pendapatan, HPP (COGS), laba kotor, dan margin per produk dan per kategori. Default hari ini
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param start_date query string false "Start Date (YYYY-MM-DD)"
+// @Param end_date query string false "End Date (YYYY-MM-DD)"
+// @Success 200 {object} model.Response
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /api/report/profit [get]
+func (h *TransactionHandler) GetProfitReport(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	report, err := h.service.GetProfitReport(startDate, endDate)
+	if err != nil {
+		model.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully get profit report", report)
+}
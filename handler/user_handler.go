@@ -2,8 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"kasir-api/model"
 	"kasir-api/service"
+	"kasir-api/utils"
 	"net/http"
 	"strconv"
 )
@@ -26,6 +28,7 @@ func NewUserHandler(service *service.UserService) *UserHandler {
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.Response
+// @Security BearerAuth
 // @Router /api/users [get]
 func (hdlr *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	users, err := hdlr.service.GetAll()
@@ -36,6 +39,120 @@ func (hdlr *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	model.Success(w, http.StatusOK, "successfully get users", users)
 }
 
+// Create godoc
+// @Summary Create user
+// @Description Membuat user baru dengan role tertentu. Hanya owner yang boleh membuat akun owner
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body model.CreateUserRequest true "User Data" SchemaExample({"name": "Kasir Satu", "email": "kasir1@email.example", "password": "#password123", "role": "cashier"})
+// @Success 201 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Router /api/users [post]
+func (hdlr *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req model.CreateUserRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	claims, _ := utils.ClaimsFromContext(r.Context())
+	user, err := hdlr.service.Create(claims.Role, req)
+	if errors.Is(err, service.ErrForbidden) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusCreated, "successfully created user", user)
+}
+
+// GetMe godoc
+// @Summary Get current user
+// @Description Mengambil profil user yang sedang login beserta informasi token yang dipakai
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} model.Response
+// @Failure 401 {object} model.Response
+// @Failure 403 {object} model.Response
+// @Security BearerAuth
+// @Router /api/auth/me [get]
+func (hdlr *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	claims, _ := utils.ClaimsFromContext(r.Context())
+
+	// API key tidak terikat ke user mana pun, jadi tidak punya profil
+	if claims.APIKeyID != 0 {
+		model.Error(w, http.StatusForbidden, "api keys have no user profile")
+		return
+	}
+
+	user, err := hdlr.service.GetByID(claims.UserID)
+	if err != nil {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	info := model.TokenInfo{
+		UserID:     claims.UserID,
+		Role:       claims.Role,
+		Scopes:     claims.Scopes,
+		TerminalID: claims.TerminalID,
+	}
+	if claims.IssuedAt != nil {
+		info.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		info.ExpiresAt = claims.ExpiresAt.Time
+	}
+
+	model.Success(w, http.StatusOK, "successfully get current user", model.MeResponse{User: user, Token: info})
+}
+
+// UpdateMe godoc
+// @Summary Update current user
+// @Description Mengubah nama dan email user yang sedang login tanpa perlu akses admin
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body model.UpdateUserRequest true "User Data" SchemaExample({"name": "User Name", "email": "user@email.example"})
+// @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
+// @Security BearerAuth
+// @Router /api/auth/me [put]
+func (hdlr *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	var req model.UpdateUserRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	claims, _ := utils.ClaimsFromContext(r.Context())
+	user := model.User{
+		ID:    claims.UserID,
+		Name:  req.Name,
+		Email: req.Email,
+	}
+
+	err := hdlr.service.Update(claims.Role, &user)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	updated, err := hdlr.service.GetByID(claims.UserID)
+	if err != nil {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated profile", updated)
+}
+
 // GetById godoc
 // @Summary Get User by ID
 // @Description Get a single user data by ID
@@ -45,12 +162,14 @@ func (hdlr *UserHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "User ID"
 // @Success 200 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Security BearerAuth
 // @Router /api/users/{id} [get]
 func (hdlr *UserHandler) GetById(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		model.Error(w, http.StatusBadRequest, "Invalid User ID")
+		return
 	}
 
 	user, err := hdlr.service.GetByID(id)
@@ -71,6 +190,7 @@ func (hdlr *UserHandler) GetById(w http.ResponseWriter, r *http.Request) {
 // @Param user body model.User true "User Data"
 // @Success 200 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Security BearerAuth
 // @Router /api/users/{id} [put]
 func (hdlr *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -88,7 +208,12 @@ func (hdlr *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user.ID = id
-	err = hdlr.service.Update(&user)
+	claims, _ := utils.ClaimsFromContext(r.Context())
+	err = hdlr.service.Update(claims.Role, &user)
+	if errors.Is(err, service.ErrForbidden) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		model.Error(w, http.StatusNotFound, err.Error())
 		return
@@ -97,6 +222,137 @@ func (hdlr *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 	model.Success(w, http.StatusOK, "successfully updated user", user)
 }
 
+// UpdateRole godoc
+// @Summary Update user role
+// @Description Mengubah role user (owner, admin, cashier). Hanya owner yang boleh mengubah role
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body model.UpdateRoleRequest true "New Role" SchemaExample({"role": "admin"})
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Router /api/users/{id}/role [put]
+func (hdlr *UserHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req model.UpdateRoleRequest
+	if err := utils.BindAndValidate(r, &req); err != nil {
+		model.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Owner tidak boleh menurunkan role dirinya sendiri, supaya sistem tidak kehilangan owner
+	if actorID, _ := utils.UserIDFromContext(r.Context()); actorID == id {
+		model.Error(w, http.StatusBadRequest, "cannot change your own role")
+		return
+	}
+
+	err = hdlr.service.UpdateRole(id, req.Role)
+	if err != nil {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated user role", nil)
+}
+
+// Unlock godoc
+// @Summary Unlock user
+// @Description Membuka kunci akun yang terkunci karena terlalu banyak gagal login
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Router /api/users/{id}/unlock [post]
+func (hdlr *UserHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid User ID")
+		return
+	}
+
+	claims, _ := utils.ClaimsFromContext(r.Context())
+	err = hdlr.service.Unlock(claims.Role, id)
+	if errors.Is(err, service.ErrForbidden) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully unlocked user", nil)
+}
+
+// Deactivate godoc
+// @Summary Deactivate user
+// @Description Menonaktifkan user sehingga tidak bisa login lagi. Semua sesi (refresh token) user ikut di-revoke
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Router /api/users/{id}/deactivate [post]
+func (hdlr *UserHandler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	hdlr.setActive(w, r, false)
+}
+
+// Activate godoc
+// @Summary Activate user
+// @Description Mengaktifkan kembali user yang sebelumnya dinonaktifkan
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} model.Response
+// @Failure 404 {object} model.Response
+// @Security BearerAuth
+// @Router /api/users/{id}/activate [post]
+func (hdlr *UserHandler) Activate(w http.ResponseWriter, r *http.Request) {
+	hdlr.setActive(w, r, true)
+}
+
+func (hdlr *UserHandler) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	idStr := r.PathValue("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		model.Error(w, http.StatusBadRequest, "Invalid User ID")
+		return
+	}
+
+	claims, _ := utils.ClaimsFromContext(r.Context())
+	if claims.UserID == id {
+		model.Error(w, http.StatusBadRequest, "cannot change your own active status")
+		return
+	}
+
+	err = hdlr.service.SetActive(claims.Role, id, active)
+	if errors.Is(err, service.ErrForbidden) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		model.Error(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	model.Success(w, http.StatusOK, "successfully updated user status", nil)
+}
+
 // Delete godoc
 // @Summary Delete user
 // @Description Menghapus produk berdasarkan ID
@@ -105,7 +361,9 @@ func (hdlr *UserHandler) Update(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} model.Response
+// @Failure 400 {object} model.Response
 // @Failure 404 {object} model.Response
+// @Security BearerAuth
 // @Router /api/users/{id} [delete]
 func (hdlr *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
@@ -115,7 +373,17 @@ func (hdlr *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = hdlr.service.Delete(id)
+	claims, _ := utils.ClaimsFromContext(r.Context())
+	if claims.UserID == id {
+		model.Error(w, http.StatusBadRequest, "cannot delete your own account")
+		return
+	}
+
+	err = hdlr.service.Delete(claims.Role, id)
+	if errors.Is(err, service.ErrForbidden) {
+		model.Error(w, http.StatusForbidden, err.Error())
+		return
+	}
 	if err != nil {
 		model.Error(w, http.StatusNotFound, err.Error())
 		return
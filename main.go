@@ -10,26 +10,50 @@ import (
 	"kasir-api/database"
 	_ "kasir-api/docs"
 	"kasir-api/handler"
+	"kasir-api/middleware"
+	"kasir-api/model"
+	"kasir-api/notifier"
 	"kasir-api/repositories"
 	"kasir-api/service"
+	"kasir-api/utils"
 
 	"github.com/spf13/viper"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type Config struct {
-	Port      string `mapstructure:"PORT"`
-	DBConn    string `mapstructure:"DB_CONN"`
-	JWTSecret string `mapstructure:"JWT_SECRET"`
+	Port               string `mapstructure:"PORT"`
+	DBConn             string `mapstructure:"DB_CONN"`
+	JWTSecret          string `mapstructure:"JWT_SECRET"`
+	Notifier           string `mapstructure:"NOTIFIER"`
+	NotifierFile       string `mapstructure:"NOTIFIER_FILE"`
+	NotifierWebhookURL string `mapstructure:"NOTIFIER_WEBHOOK_URL"`
+	// LowStockNotifier adalah driver notifier untuk alert stok menipis (log, file, webhook)
+	LowStockNotifier   string `mapstructure:"LOW_STOCK_NOTIFIER"`
+	LowStockWebhookURL string `mapstructure:"LOW_STOCK_WEBHOOK_URL"`
+	// TwoFactorRoles berisi role yang wajib 2FA, dipisah koma (misal "owner,admin")
+	TwoFactorRoles string `mapstructure:"TWO_FACTOR_ROLES"`
+	// AllowRegistration membuka/menutup registrasi publik lewat /api/auth/register
+	AllowRegistration bool `mapstructure:"ALLOW_REGISTRATION"`
+	// TrustedProxies berisi IP/CIDR reverse proxy yang boleh mengisi X-Forwarded-For, dipisah koma
+	TrustedProxies string `mapstructure:"TRUSTED_PROXIES"`
 }
 
 // @title Kasir API
 // @version 1.0
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Format: "Bearer {token}"
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
 
 func main() {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.SetDefault("ALLOW_REGISTRATION", true)
 
 	if _, err := os.Stat(".env"); err == nil {
 		viper.SetConfigFile(".env")
@@ -37,9 +61,27 @@ func main() {
 	}
 
 	config := Config{
-		Port:      viper.GetString("PORT"),
-		DBConn:    viper.GetString("DB_CONN"),
-		JWTSecret: viper.GetString("JWT_SECRET"),
+		Port:         viper.GetString("PORT"),
+		DBConn:       viper.GetString("DB_CONN"),
+		JWTSecret:    viper.GetString("JWT_SECRET"),
+		Notifier:     viper.GetString("NOTIFIER"),
+		NotifierFile: viper.GetString("NOTIFIER_FILE"),
+
+		NotifierWebhookURL: viper.GetString("NOTIFIER_WEBHOOK_URL"),
+		LowStockNotifier:   viper.GetString("LOW_STOCK_NOTIFIER"),
+		LowStockWebhookURL: viper.GetString("LOW_STOCK_WEBHOOK_URL"),
+
+		TwoFactorRoles:    viper.GetString("TWO_FACTOR_ROLES"),
+		AllowRegistration: viper.GetBool("ALLOW_REGISTRATION"),
+		TrustedProxies:    viper.GetString("TRUSTED_PROXIES"),
+	}
+
+	if config.JWTSecret == "" {
+		log.Fatal("JWT_SECRET must be set")
+	}
+
+	if err := utils.SetTrustedProxies(config.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
 	}
 
 	db, err := database.InitDB(config.DBConn)
@@ -53,20 +95,66 @@ func main() {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
+	twoFactorRoles := make([]string, 0)
+	for _, role := range strings.Split(config.TwoFactorRoles, ",") {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		if !model.IsValidRole(role) {
+			log.Fatalf("Invalid role %q in TWO_FACTOR_ROLES", role)
+		}
+		twoFactorRoles = append(twoFactorRoles, role)
+	}
+
 	// Dependency Injection
+	// Notifier
+	userNotifier, err := notifier.New(config.Notifier, config.NotifierFile, config.NotifierWebhookURL)
+	if err != nil {
+		log.Fatal("Failed to initialize notifier:", err)
+	}
+	stockNotifier, err := notifier.New(config.LowStockNotifier, config.NotifierFile, config.LowStockWebhookURL)
+	if err != nil {
+		log.Fatal("Failed to initialize low stock notifier:", err)
+	}
+
 	// Repositories
 	authRepo := repositories.NewAuthRepository(db)
 	categoryRepo := repositories.NewCategoryRepository(db)
 	productRepo := repositories.NewProductRepository(db)
 	userRepo := repositories.NewUserRepository(db)
 	transactionRepo := repositories.NewTransactionRepository(db)
+	terminalRepo := repositories.NewTerminalRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	stockRepo := repositories.NewStockRepository(db)
+	stockOpnameRepo := repositories.NewStockOpnameRepository(db)
+	supplierRepo := repositories.NewSupplierRepository(db)
+	purchaseOrderRepo := repositories.NewPurchaseOrderRepository(db)
+	productVariantRepo := repositories.NewProductVariantRepository(db)
+	productUnitRepo := repositories.NewProductUnitRepository(db)
+	recipeRepo := repositories.NewRecipeRepository(db)
+	modifierRepo := repositories.NewModifierRepository(db)
 
 	// Services
-	authService := service.NewAuthService(authRepo, config.JWTSecret)
+	authService := service.NewAuthService(authRepo, terminalRepo, userNotifier, service.AuthOptions{
+		Secret:            config.JWTSecret,
+		TwoFactorRoles:    twoFactorRoles,
+		AllowRegistration: config.AllowRegistration,
+	})
 	categoryService := service.NewCategoryService(categoryRepo)
 	productService := service.NewProductService(productRepo)
 	userService := service.NewUserService(userRepo)
-	transactionService := service.NewTransactionService(transactionRepo)
+	transactionService := service.NewTransactionService(transactionRepo, stockNotifier)
+	terminalService := service.NewTerminalService(terminalRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	stockService := service.NewStockService(stockRepo)
+	stockOpnameService := service.NewStockOpnameService(stockOpnameRepo)
+	supplierService := service.NewSupplierService(supplierRepo)
+	purchaseOrderService := service.NewPurchaseOrderService(purchaseOrderRepo)
+	productVariantService := service.NewProductVariantService(productVariantRepo)
+	productUnitService := service.NewProductUnitService(productUnitRepo)
+	recipeService := service.NewRecipeService(recipeRepo)
+	modifierService := service.NewModifierService(modifierRepo)
 
 	// Handlers
 	authHandler := handler.NewAuthHandler(authService)
@@ -74,6 +162,31 @@ func main() {
 	productHandler := handler.NewProductHandler(productService)
 	userHandler := handler.NewUserHandler(userService)
 	transactionHandler := handler.NewTransactionHandler(transactionService)
+	terminalHandler := handler.NewTerminalHandler(terminalService)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	stockHandler := handler.NewStockHandler(stockService)
+	stockOpnameHandler := handler.NewStockOpnameHandler(stockOpnameService)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+	productVariantHandler := handler.NewProductVariantHandler(productVariantService)
+	productUnitHandler := handler.NewProductUnitHandler(productUnitService)
+	recipeHandler := handler.NewRecipeHandler(recipeService)
+	modifierHandler := handler.NewModifierHandler(modifierService)
+
+	// Middleware
+	authMiddleware := middleware.NewAuthMiddleware(config.JWTSecret, apiKeyService, userService)
+	catalogRead := authMiddleware.Require(model.PermCatalogRead)
+	catalogWrite := authMiddleware.Require(model.PermCatalogWrite)
+	checkout := authMiddleware.Require(model.PermCheckout)
+	reportRead := authMiddleware.Require(model.PermReportRead)
+	userManage := authMiddleware.Require(model.PermUserManage)
+	userRole := authMiddleware.Require(model.PermUserRole)
+	auditRead := authMiddleware.Require(model.PermAuditRead)
+	profile := authMiddleware.Require(model.PermProfile)
+	terminalManage := authMiddleware.Require(model.PermTerminal)
+	apiKeyManage := authMiddleware.Require(model.PermAPIKey)
+	twoFactor := authMiddleware.Require(model.PermTwoFactor)
+	inventory := authMiddleware.Require(model.PermInventory)
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("API ready!"))
@@ -82,31 +195,120 @@ func main() {
 	// Register routes - Auth (Persiapan aja, Kreatif:v)
 	http.HandleFunc("POST /api/auth/register", authHandler.Register)
 	http.HandleFunc("POST /api/auth/login", authHandler.Login)
+	http.HandleFunc("POST /api/auth/refresh", authHandler.Refresh)
+	http.HandleFunc("POST /api/auth/logout", authHandler.Logout)
+	http.HandleFunc("POST /api/auth/change-password", profile(authHandler.ChangePassword))
+	http.HandleFunc("POST /api/auth/forgot-password", authHandler.ForgotPassword)
+	http.HandleFunc("POST /api/auth/reset-password", authHandler.ResetPassword)
+	http.HandleFunc("GET /api/auth/login-attempts", auditRead(authHandler.GetLoginAttempts))
+	http.HandleFunc("GET /api/auth/me", authMiddleware.Authenticate(userHandler.GetMe))
+	http.HandleFunc("PUT /api/auth/me", profile(userHandler.UpdateMe))
+	http.HandleFunc("PUT /api/auth/me/pin", profile(authHandler.SetPin))
+	http.HandleFunc("POST /api/auth/pin-login", authHandler.PinLogin)
+	http.HandleFunc("POST /api/auth/2fa/verify", authHandler.VerifyTwoFactor)
+	http.HandleFunc("POST /api/auth/2fa/setup", twoFactor(authHandler.SetupTwoFactor))
+	http.HandleFunc("POST /api/auth/2fa/enable", twoFactor(authHandler.EnableTwoFactor))
+	http.HandleFunc("POST /api/auth/2fa/disable", twoFactor(authHandler.DisableTwoFactor))
 
 	// Register routes - Products
-	http.HandleFunc("GET /api/products", productHandler.GetAll)
-	http.HandleFunc("GET /api/products/{id}", productHandler.GetByID)
-	http.HandleFunc("POST /api/products", productHandler.Create)
-	http.HandleFunc("PUT /api/products/{id}", productHandler.Update)
-	http.HandleFunc("DELETE /api/products/{id}", productHandler.Delete)
+	http.HandleFunc("GET /api/products", catalogRead(productHandler.GetAll))
+	http.HandleFunc("GET /api/products/{id}", catalogRead(productHandler.GetByID))
+	http.HandleFunc("GET /api/products/barcode/{code}", catalogRead(productHandler.GetByBarcode))
+	http.HandleFunc("GET /api/products/low-stock", catalogRead(productHandler.GetLowStock))
+	http.HandleFunc("GET /api/products/expiring", catalogRead(productHandler.GetExpiring))
+	http.HandleFunc("GET /api/products/archived", catalogRead(productHandler.GetArchived))
+	http.HandleFunc("GET /api/products/{id}/lots", catalogRead(productHandler.GetLots))
+	http.HandleFunc("POST /api/products", catalogWrite(productHandler.Create))
+	http.HandleFunc("PUT /api/products/{id}", catalogWrite(productHandler.Update))
+	http.HandleFunc("DELETE /api/products/{id}", catalogWrite(productHandler.Delete))
+	http.HandleFunc("POST /api/products/{id}/restore", catalogWrite(productHandler.Restore))
+
+	// Register routes - Product Variants
+	http.HandleFunc("GET /api/products/{id}/variants", catalogRead(productVariantHandler.GetByProduct))
+	http.HandleFunc("POST /api/products/{id}/variants", catalogWrite(productVariantHandler.Create))
+	http.HandleFunc("PUT /api/products/{id}/variants/{variantId}", catalogWrite(productVariantHandler.Update))
+	http.HandleFunc("DELETE /api/products/{id}/variants/{variantId}", catalogWrite(productVariantHandler.Delete))
+
+	// Register routes - Product Units
+	http.HandleFunc("GET /api/products/{id}/units", catalogRead(productUnitHandler.GetByProduct))
+	http.HandleFunc("POST /api/products/{id}/units", catalogWrite(productUnitHandler.Create))
+	http.HandleFunc("PUT /api/products/{id}/units/{unitId}", catalogWrite(productUnitHandler.Update))
+	http.HandleFunc("DELETE /api/products/{id}/units/{unitId}", catalogWrite(productUnitHandler.Delete))
+
+	// Register routes - Recipes
+	http.HandleFunc("GET /api/products/{id}/recipe", catalogRead(recipeHandler.GetByProduct))
+	http.HandleFunc("PUT /api/products/{id}/recipe", catalogWrite(recipeHandler.Set))
+
+	// Register routes - Modifiers
+	http.HandleFunc("GET /api/products/{id}/modifier-groups", catalogRead(modifierHandler.GetByProduct))
+	http.HandleFunc("POST /api/products/{id}/modifier-groups", catalogWrite(modifierHandler.Create))
+	http.HandleFunc("PUT /api/products/{id}/modifier-groups/{groupId}", catalogWrite(modifierHandler.Update))
+	http.HandleFunc("DELETE /api/products/{id}/modifier-groups/{groupId}", catalogWrite(modifierHandler.Delete))
+
+	// Register routes - Stock
+	http.HandleFunc("GET /api/products/{id}/stock-movements", inventory(stockHandler.GetByProduct))
+	http.HandleFunc("POST /api/products/{id}/stock-movements", inventory(stockHandler.Create))
+
+	// Register routes - Stock Opname
+	http.HandleFunc("GET /api/stock-opnames", inventory(stockOpnameHandler.GetAll))
+	http.HandleFunc("POST /api/stock-opnames", inventory(stockOpnameHandler.Start))
+	http.HandleFunc("GET /api/stock-opnames/{id}", inventory(stockOpnameHandler.GetByID))
+	http.HandleFunc("POST /api/stock-opnames/{id}/items", inventory(stockOpnameHandler.SubmitCounts))
+	http.HandleFunc("POST /api/stock-opnames/{id}/finalize", inventory(stockOpnameHandler.Finalize))
+	http.HandleFunc("POST /api/stock-opnames/{id}/cancel", inventory(stockOpnameHandler.Cancel))
+
+	// Register routes - Suppliers
+	http.HandleFunc("GET /api/suppliers", inventory(supplierHandler.GetAll))
+	http.HandleFunc("POST /api/suppliers", inventory(supplierHandler.Create))
+	http.HandleFunc("GET /api/suppliers/{id}", inventory(supplierHandler.GetByID))
+	http.HandleFunc("PUT /api/suppliers/{id}", inventory(supplierHandler.Update))
+	http.HandleFunc("DELETE /api/suppliers/{id}", inventory(supplierHandler.Delete))
+
+	// Register routes - Purchase Orders
+	http.HandleFunc("GET /api/purchase-orders", inventory(purchaseOrderHandler.GetAll))
+	http.HandleFunc("POST /api/purchase-orders", inventory(purchaseOrderHandler.Create))
+	http.HandleFunc("GET /api/purchase-orders/{id}", inventory(purchaseOrderHandler.GetByID))
+	http.HandleFunc("POST /api/purchase-orders/{id}/send", inventory(purchaseOrderHandler.Send))
+	http.HandleFunc("POST /api/purchase-orders/{id}/cancel", inventory(purchaseOrderHandler.Cancel))
+	http.HandleFunc("POST /api/purchase-orders/{id}/receive", inventory(purchaseOrderHandler.Receive))
 
 	// Register routes - Categories
-	http.HandleFunc("GET /api/categories", categoryHandler.HandleCategories)
-	http.HandleFunc("GET /api/categories/{id}", categoryHandler.HandleCategoryByID)
-	http.HandleFunc("POST /api/categories", categoryHandler.HandleCategories)
-	http.HandleFunc("PUT /api/categories/{id}", categoryHandler.HandleCategoryByID)
-	http.HandleFunc("DELETE /api/categories/{id}", categoryHandler.HandleCategoryByID)
+	http.HandleFunc("GET /api/categories", catalogRead(categoryHandler.HandleCategories))
+	http.HandleFunc("GET /api/categories/tree", catalogRead(categoryHandler.GetTree))
+	http.HandleFunc("GET /api/categories/{id}", catalogRead(categoryHandler.HandleCategoryByID))
+	http.HandleFunc("POST /api/categories", catalogWrite(categoryHandler.HandleCategories))
+	http.HandleFunc("PUT /api/categories/{id}", catalogWrite(categoryHandler.HandleCategoryByID))
+	http.HandleFunc("PUT /api/categories/{id}/parent", catalogWrite(categoryHandler.Move))
+	http.HandleFunc("DELETE /api/categories/{id}", catalogWrite(categoryHandler.HandleCategoryByID))
 
 	// Register routes - Users (Persiapan aja, Kreatif:v)
-	http.HandleFunc("GET /api/users", userHandler.GetAll)
-	http.HandleFunc("GET /api/users/{id}", userHandler.GetById)
-	http.HandleFunc("PUT /api/users/{id}", userHandler.Update)
-	http.HandleFunc("DELETE /api/users/{id}", userHandler.Delete)
+	http.HandleFunc("GET /api/users", userManage(userHandler.GetAll))
+	http.HandleFunc("POST /api/users", userManage(userHandler.Create))
+	http.HandleFunc("GET /api/users/{id}", userManage(userHandler.GetById))
+	http.HandleFunc("PUT /api/users/{id}", userManage(userHandler.Update))
+	http.HandleFunc("PUT /api/users/{id}/role", userRole(userHandler.UpdateRole))
+	http.HandleFunc("POST /api/users/{id}/unlock", userManage(userHandler.Unlock))
+	http.HandleFunc("POST /api/users/{id}/deactivate", userManage(userHandler.Deactivate))
+	http.HandleFunc("POST /api/users/{id}/activate", userManage(userHandler.Activate))
+	http.HandleFunc("DELETE /api/users/{id}", userManage(userHandler.Delete))
+
+	// Register routes - Terminals
+	http.HandleFunc("GET /api/terminals", terminalManage(terminalHandler.GetAll))
+	http.HandleFunc("POST /api/terminals", terminalManage(terminalHandler.Create))
+	http.HandleFunc("POST /api/terminals/{id}/deactivate", terminalManage(terminalHandler.Deactivate))
+	http.HandleFunc("POST /api/terminals/{id}/activate", terminalManage(terminalHandler.Activate))
+
+	// Register routes - API Keys
+	http.HandleFunc("GET /api/api-keys", apiKeyManage(apiKeyHandler.GetAll))
+	http.HandleFunc("POST /api/api-keys", apiKeyManage(apiKeyHandler.Create))
+	http.HandleFunc("DELETE /api/api-keys/{id}", apiKeyManage(apiKeyHandler.Revoke))
 
 	// Register routes - Transactions
-	http.HandleFunc("POST /api/checkout", transactionHandler.HandleCheckout)
-	http.HandleFunc("GET /api/report/hari-ini", transactionHandler.GetTodaySummary)
-	http.HandleFunc("GET /api/report", transactionHandler.GetSummaryByRange)
+	http.HandleFunc("POST /api/checkout", checkout(transactionHandler.HandleCheckout))
+	http.HandleFunc("GET /api/transactions/{id}", checkout(transactionHandler.GetByID))
+	http.HandleFunc("GET /api/report/hari-ini", reportRead(transactionHandler.GetTodaySummary))
+	http.HandleFunc("GET /api/report", reportRead(transactionHandler.GetSummaryByRange))
+	http.HandleFunc("GET /api/report/profit", reportRead(transactionHandler.GetProfitReport))
 
 	// Swagger
 	http.HandleFunc("/swagger/", httpSwagger.WrapHandler)
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"kasir-api/model"
+	"kasir-api/service"
+	"kasir-api/utils"
+)
+
+const (
+	// TerminalKeyHeader adalah header berisi key terminal kasir yang didaftarkan admin
+	TerminalKeyHeader = "X-Terminal-Key"
+	// APIKeyHeader adalah header untuk integrasi machine-to-machine memakai API key
+	APIKeyHeader = "X-API-Key"
+)
+
+type AuthMiddleware struct {
+	secret  string
+	apiKeys *service.APIKeyService
+	users   *service.UserService
+}
+
+func NewAuthMiddleware(secret string, apiKeys *service.APIKeyService, users *service.UserService) *AuthMiddleware {
+	return &AuthMiddleware{secret: secret, apiKeys: apiKeys, users: users}
+}
+
+// Authenticate memastikan request membawa Bearer token atau API key yang valid
+// dan menyimpan claims-nya ke dalam context request
+func (m *AuthMiddleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(APIKeyHeader); key != "" {
+			apiKey, err := m.apiKeys.Validate(key)
+			if err != nil {
+				model.Error(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+
+			claims := &utils.Claims{APIKeyID: apiKey.ID, Scopes: apiKey.Scopes}
+			next(w, r.WithContext(utils.ContextWithClaims(r.Context(), claims)))
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || tokenString == "" {
+			model.Error(w, http.StatusUnauthorized, "missing or malformed authorization token")
+			return
+		}
+
+		claims, err := utils.ParseToken(tokenString, m.secret)
+		if err != nil || claims.Purpose != "" {
+			model.Error(w, http.StatusUnauthorized, "invalid or expired token")
+			return
+		}
+
+		// Token hasil PIN login hanya berlaku di terminal tempat token itu dibuat
+		if claims.TerminalKeyHash != "" {
+			terminalKey := r.Header.Get(TerminalKeyHeader)
+			if terminalKey == "" || utils.HashToken(terminalKey) != claims.TerminalKeyHash {
+				model.Error(w, http.StatusUnauthorized, "token is not valid for this terminal")
+				return
+			}
+		}
+
+		// Access token tetap valid sampai kedaluwarsa, jadi status user dicek di
+		// setiap request supaya user yang dinonaktifkan atau dihapus langsung terputus
+		active, err := m.users.IsActive(claims.UserID)
+		if err != nil {
+			model.Error(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !active {
+			model.Error(w, http.StatusUnauthorized, "account has been deactivated")
+			return
+		}
+
+		next(w, r.WithContext(utils.ContextWithClaims(r.Context(), claims)))
+	}
+}
+
+// Require mengautentikasi request lalu memastikan role user (atau scope API key)
+// punya permission yang diminta
+func (m *AuthMiddleware) Require(perm string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return m.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+			claims, _ := utils.ClaimsFromContext(r.Context())
+			if !allowed(claims, perm) {
+				model.Error(w, http.StatusForbidden, "you do not have permission to access this resource")
+				return
+			}
+
+			next(w, r)
+		})
+	}
+}
+
+func allowed(claims *utils.Claims, perm string) bool {
+	// API key tidak punya role, permission-nya murni dari scope yang diberikan admin
+	if claims.APIKeyID != 0 {
+		return len(claims.Scopes) > 0 && claims.HasScope(perm)
+	}
+	return model.HasPermission(claims.Role, perm) && claims.HasScope(perm)
+}
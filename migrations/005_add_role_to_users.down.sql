@@ -0,0 +1,4 @@
+-- Migration: Drop role from users
+-- Description: Rollback untuk menghapus kolom role dari tabel users
+
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,9 @@
+-- Migration: Add role to users
+-- Description: Menambahkan kolom role (owner, admin, cashier) pada tabel users
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'cashier'
+    CHECK (role IN ('owner', 'admin', 'cashier'));
+
+-- User pertama yang sudah ada dijadikan owner supaya sistem tetap bisa dikelola
+UPDATE users SET role = 'owner' WHERE id = (SELECT MIN(id) FROM users);
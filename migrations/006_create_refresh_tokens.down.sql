@@ -0,0 +1,4 @@
+-- Migration: Drop refresh_tokens table
+-- Description: Rollback untuk menghapus tabel refresh_tokens
+
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,4 @@
+-- Migration: Drop password_resets table
+-- Description: Rollback untuk menghapus tabel password_resets
+
+DROP TABLE IF EXISTS password_resets;
@@ -0,0 +1,8 @@
+-- Migration: Rollback login brute-force protection
+-- Description: Menghapus tabel login_attempts dan kolom lockout pada users
+
+DROP TABLE IF EXISTS login_attempts;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS failed_login_attempts,
+    DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,6 @@
+-- Migration: Rollback PIN login
+-- Description: Menghapus tabel terminals dan kolom pin_hash pada users
+
+DROP TABLE IF EXISTS terminals;
+
+ALTER TABLE users DROP COLUMN IF EXISTS pin_hash;
@@ -0,0 +1,12 @@
+-- Migration: PIN login untuk terminal kasir
+-- Description: Menambahkan kolom pin_hash pada users dan tabel terminals untuk perangkat POS yang terdaftar
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pin_hash VARCHAR(255);
+
+CREATE TABLE IF NOT EXISTS terminals (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(64) UNIQUE NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+-- Migration: Drop api_keys table
+-- Description: Rollback untuk menghapus tabel api_keys
+
+DROP TABLE IF EXISTS api_keys;
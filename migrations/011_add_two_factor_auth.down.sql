@@ -0,0 +1,8 @@
+-- Migration: Rollback two-factor authentication
+-- Description: Menghapus tabel backup_codes dan kolom TOTP pada users
+
+DROP TABLE IF EXISTS backup_codes;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS totp_secret,
+    DROP COLUMN IF EXISTS totp_enabled;
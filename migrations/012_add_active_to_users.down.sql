@@ -0,0 +1,4 @@
+-- Migration: Drop active flag from users
+-- Description: Rollback untuk menghapus kolom active dari tabel users
+
+ALTER TABLE users DROP COLUMN IF EXISTS active;
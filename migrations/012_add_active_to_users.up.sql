@@ -0,0 +1,4 @@
+-- Migration: Add active flag to users
+-- Description: User yang dinonaktifkan tidak bisa login, tanpa harus menghapus datanya
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
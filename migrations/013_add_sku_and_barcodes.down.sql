@@ -0,0 +1,6 @@
+-- Migration: Drop SKU and barcodes from products
+-- Description: Rollback untuk menghapus tabel product_barcodes dan kolom sku
+
+DROP TABLE IF EXISTS product_barcodes;
+
+ALTER TABLE products DROP COLUMN IF EXISTS sku;
@@ -0,0 +1,12 @@
+-- Migration: Add SKU and barcodes to products
+-- Description: SKU unik per produk, dan satu produk bisa punya lebih dari satu barcode (EAN/UPC)
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64) UNIQUE;
+
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    code VARCHAR(13) NOT NULL UNIQUE
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id ON product_barcodes(product_id);
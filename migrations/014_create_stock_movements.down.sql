@@ -0,0 +1,4 @@
+-- Migration: Drop stock_movements table
+-- Description: Rollback untuk menghapus ledger stok
+
+DROP TABLE IF EXISTS stock_movements;
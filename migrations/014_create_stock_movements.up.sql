@@ -0,0 +1,22 @@
+-- Migration: Create stock_movements table
+-- Description: Ledger append-only untuk setiap perubahan stok produk (sale, restock, adjustment, return, waste)
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('sale', 'restock', 'adjustment', 'return', 'waste')),
+    quantity INT NOT NULL,
+    stock_after INT NOT NULL,
+    reason TEXT,
+    reference VARCHAR(100),
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements(product_id, created_at);
+
+-- Saldo awal supaya ledger konsisten dengan stok yang sudah ada
+INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason)
+SELECT id, 'adjustment', stock, stock, 'saldo awal'
+FROM products
+WHERE stock <> 0;
@@ -0,0 +1,5 @@
+-- Migration: Drop stock opname tables
+-- Description: Rollback untuk menghapus tabel stock opname
+
+DROP TABLE IF EXISTS stock_opname_items;
+DROP TABLE IF EXISTS stock_opnames;
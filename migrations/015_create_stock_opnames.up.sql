@@ -0,0 +1,27 @@
+-- Migration: Create stock opname tables
+-- Description: Sesi hitung fisik stok (stock opname) beserta hasil hitungan per produk
+
+CREATE TABLE IF NOT EXISTS stock_opnames (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'finalized', 'cancelled')),
+    note TEXT,
+    started_by INT REFERENCES users(id) ON DELETE SET NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finalized_by INT REFERENCES users(id) ON DELETE SET NULL,
+    finalized_at TIMESTAMP
+);
+
+-- Hanya boleh ada satu sesi opname yang sedang berjalan
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_opnames_single_open ON stock_opnames(status) WHERE status = 'open';
+
+CREATE TABLE IF NOT EXISTS stock_opname_items (
+    id SERIAL PRIMARY KEY,
+    opname_id INT NOT NULL REFERENCES stock_opnames(id) ON DELETE CASCADE,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    system_stock INT NOT NULL,
+    counted_qty INT NOT NULL CHECK (counted_qty >= 0),
+    unit_price INT NOT NULL,
+    counted_by INT REFERENCES users(id) ON DELETE SET NULL,
+    counted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (opname_id, product_id)
+);
@@ -0,0 +1,4 @@
+-- Migration: Drop min_stock from products
+-- Description: Rollback untuk menghapus kolom min_stock dari tabel products
+
+ALTER TABLE products DROP COLUMN IF EXISTS min_stock;
@@ -0,0 +1,4 @@
+-- Migration: Add min_stock to products
+-- Description: Batas minimum stok (reorder point) per produk untuk alert stok menipis. 0 berarti tidak dipantau
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS min_stock INT NOT NULL DEFAULT 0 CHECK (min_stock >= 0);
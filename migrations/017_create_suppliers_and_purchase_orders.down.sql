@@ -0,0 +1,6 @@
+-- Migration: Drop suppliers and purchase orders tables
+-- Description: Rollback untuk menghapus tabel purchase order dan supplier
+
+DROP TABLE IF EXISTS purchase_order_items;
+DROP TABLE IF EXISTS purchase_orders;
+DROP TABLE IF EXISTS suppliers;
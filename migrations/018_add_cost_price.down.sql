@@ -0,0 +1,8 @@
+-- Migration: Drop cost price tracking
+-- Description: Rollback untuk menghapus kolom cost_price dan unit_cost
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS unit_cost;
+
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS unit_cost;
+
+ALTER TABLE products DROP COLUMN IF EXISTS cost_price;
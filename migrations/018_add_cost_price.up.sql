@@ -0,0 +1,9 @@
+-- Migration: Add cost price tracking
+-- Description: Harga pokok (HPP) per produk dengan rata-rata tertimbang saat restock,
+-- snapshot HPP per baris transaksi, dan harga beli per movement stok
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price INT NOT NULL DEFAULT 0 CHECK (cost_price >= 0);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_cost INT NOT NULL DEFAULT 0;
+
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS unit_cost INT;
@@ -0,0 +1,8 @@
+-- Migration: Drop product_variants table
+-- Description: Rollback untuk menghapus varian produk
+
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS variant_id;
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS variant_id;
+
+DROP TABLE IF EXISTS product_variants;
@@ -0,0 +1,21 @@
+-- Migration: Create product_variants table
+-- Description: Varian produk (ukuran, warna, dll) dengan SKU, harga override, dan stok sendiri.
+-- Stok produk induk adalah jumlah stok semua variannya
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    options JSONB NOT NULL DEFAULT '{}',
+    sku VARCHAR(64) UNIQUE,
+    price INT CHECK (price >= 0),
+    stock INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants(product_id);
+
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE RESTRICT;
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE RESTRICT;
@@ -0,0 +1,4 @@
+-- Migration: Drop product_recipes table
+-- Description: Rollback untuk menghapus resep produk komposit
+
+DROP TABLE IF EXISTS product_recipes;
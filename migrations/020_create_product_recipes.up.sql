@@ -0,0 +1,13 @@
+-- Migration: Create product_recipes table
+-- Description: Resep (bill of materials) untuk produk komposit. Menjual satu
+-- produk komposit mengurangi stok bahan-bahannya, bukan stok produk itu sendiri
+
+CREATE TABLE IF NOT EXISTS product_recipes (
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    ingredient_id INT NOT NULL REFERENCES products(id) ON DELETE RESTRICT,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (product_id, ingredient_id),
+    CHECK (product_id <> ingredient_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_recipes_ingredient_id ON product_recipes(ingredient_id);
@@ -0,0 +1,8 @@
+-- Migration: Drop modifier tables
+-- Description: Rollback untuk menghapus modifier produk
+
+DROP TABLE IF EXISTS transaction_detail_modifiers;
+
+DROP TABLE IF EXISTS modifiers;
+
+DROP TABLE IF EXISTS modifier_groups;
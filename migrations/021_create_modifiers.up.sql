@@ -0,0 +1,37 @@
+-- Migration: Create modifier groups and modifiers
+-- Description: Pilihan tambahan per produk (mis. level gula, extra topping) dengan
+-- batas jumlah pilihan dan tambahan harga. Modifier yang dipilih saat checkout
+-- di-snapshot ke transaction_detail_modifiers untuk struk dan laporan
+
+CREATE TABLE IF NOT EXISTS modifier_groups (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    min_select INT NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select INT NOT NULL DEFAULT 1 CHECK (max_select >= 1),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (product_id, name),
+    CHECK (max_select >= min_select)
+);
+
+CREATE INDEX IF NOT EXISTS idx_modifier_groups_product_id ON modifier_groups(product_id);
+
+CREATE TABLE IF NOT EXISTS modifiers (
+    id SERIAL PRIMARY KEY,
+    group_id INT NOT NULL REFERENCES modifier_groups(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    price_delta INT NOT NULL DEFAULT 0 CHECK (price_delta >= 0),
+    UNIQUE (group_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS transaction_detail_modifiers (
+    id SERIAL PRIMARY KEY,
+    transaction_detail_id INT NOT NULL REFERENCES transaction_details(id) ON DELETE CASCADE,
+    modifier_id INT REFERENCES modifiers(id) ON DELETE SET NULL,
+    group_name VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    price_delta INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_detail_modifiers_detail_id ON transaction_detail_modifiers(transaction_detail_id);
@@ -0,0 +1,8 @@
+-- Migration: Drop product_lots table
+-- Description: Rollback untuk menghapus pelacakan lot dan kedaluwarsa
+
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS lot_id;
+
+DROP TABLE IF EXISTS product_lots;
+
+ALTER TABLE products DROP COLUMN IF EXISTS track_expiry;
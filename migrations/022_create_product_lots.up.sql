@@ -0,0 +1,23 @@
+-- Migration: Create product_lots table
+-- Description: Stok produk yang dilacak kedaluwarsanya dicatat per lot (batch)
+-- dengan tanggal kedaluwarsa dan tanggal terima. Barang keluar mengurangi lot
+-- FEFO (first expired, first out). Untuk produk ini, products.stock selalu sama
+-- dengan jumlah sisa semua lot-nya
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS track_expiry BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS product_lots (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_id INT REFERENCES product_variants(id) ON DELETE RESTRICT,
+    expiry_date DATE NOT NULL,
+    received_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    remaining INT NOT NULL CHECK (remaining >= 0 AND remaining <= quantity),
+    unit_cost INT,
+    reference VARCHAR(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_lots_fefo ON product_lots(product_id, expiry_date) WHERE remaining > 0;
+
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS lot_id INT REFERENCES product_lots(id) ON DELETE RESTRICT;
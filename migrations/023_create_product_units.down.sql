@@ -0,0 +1,12 @@
+-- Migration: Drop product_units table
+-- Description: Rollback untuk menghapus satuan dan konversi produk
+
+ALTER TABLE purchase_order_items DROP COLUMN IF EXISTS unit_factor;
+ALTER TABLE purchase_order_items DROP COLUMN IF EXISTS unit_name;
+
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS unit_quantity;
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS unit_name;
+
+DROP TABLE IF EXISTS product_units;
+
+ALTER TABLE products DROP COLUMN IF EXISTS base_unit;
@@ -0,0 +1,23 @@
+-- Migration: Create product_units table
+-- Description: Satuan jual/beli per produk dengan faktor konversi ke satuan dasar
+-- (mis. 1 karton = 24 pcs, 1 kg = 1000 g). Stok selalu disimpan dalam satuan
+-- dasar, jadi untuk barang timbangan pilih satuan terkecil (g) sebagai satuan dasar
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS base_unit VARCHAR(32) NOT NULL DEFAULT 'pcs';
+
+CREATE TABLE IF NOT EXISTS product_units (
+    id SERIAL PRIMARY KEY,
+    product_id INT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,
+    factor INT NOT NULL CHECK (factor > 0),
+    price INT CHECK (price >= 0),
+    UNIQUE (product_id, name)
+);
+
+-- Satuan dan jumlah yang diinput kasir, untuk struk. quantity tetap dalam satuan dasar
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_name VARCHAR(32);
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit_quantity NUMERIC(12, 3);
+
+-- Item PO dipesan dalam satuan beli; quantity, received_qty, dan unit_cost mengikuti satuan ini
+ALTER TABLE purchase_order_items ADD COLUMN IF NOT EXISTS unit_name VARCHAR(32);
+ALTER TABLE purchase_order_items ADD COLUMN IF NOT EXISTS unit_factor INT NOT NULL DEFAULT 1 CHECK (unit_factor > 0);
@@ -0,0 +1,8 @@
+-- Migration: Drop parent_id from categories
+-- Description: Rollback untuk menghapus hierarki kategori
+
+DROP INDEX IF EXISTS idx_products_category_id;
+DROP INDEX IF EXISTS idx_categories_parent_id;
+
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_not_self;
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
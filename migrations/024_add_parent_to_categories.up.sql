@@ -0,0 +1,9 @@
+-- Migration: Add parent_id to categories
+-- Description: Kategori bertingkat (mis. Makanan > Minuman > Kopi). NULL berarti kategori root.
+-- Kategori yang masih punya sub-kategori tidak bisa dihapus
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES categories(id) ON DELETE RESTRICT;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_not_self CHECK (parent_id <> id);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products(category_id);
@@ -0,0 +1,8 @@
+-- Migration: Drop archived_at from categories
+-- Description: Rollback untuk mengembalikan ON DELETE SET NULL dan menghapus arsip kategori
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_category_id_fkey;
+ALTER TABLE products ADD CONSTRAINT products_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,9 @@
+-- Migration: Add archived_at to categories
+-- Description: Kategori bisa diarsipkan (disembunyikan) tanpa melepas produknya. Menghapus
+-- kategori yang masih punya produk tidak lagi diam-diam mengosongkan category_id produk
+
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_category_id_fkey;
+ALTER TABLE products ADD CONSTRAINT products_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT;
@@ -0,0 +1,6 @@
+-- Migration: Drop archived_at from products
+-- Description: Rollback untuk menghapus soft delete produk
+
+DROP INDEX IF EXISTS idx_products_active;
+
+ALTER TABLE products DROP COLUMN IF EXISTS archived_at;
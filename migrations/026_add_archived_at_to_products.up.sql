@@ -0,0 +1,7 @@
+-- Migration: Add archived_at to products
+-- Description: Hapus produk menjadi soft delete. Produk yang diarsipkan hilang dari katalog
+-- dan checkout, tapi tetap ada untuk riwayat transaksi, stok, dan laporan
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_products_active ON products(id) WHERE archived_at IS NULL;
@@ -0,0 +1,4 @@
+-- Migration: Drop expiry_date from stock_opname_items
+-- Description: Rollback untuk menghapus tanggal kedaluwarsa hasil hitungan opname
+
+ALTER TABLE stock_opname_items DROP COLUMN IF EXISTS expiry_date;
@@ -0,0 +1,5 @@
+-- Migration: Add expiry_date to stock_opname_items
+-- Description: Tanggal kedaluwarsa untuk surplus hitungan produk track_expiry, dipakai
+-- sebagai lot baru saat opname difinalisasi
+
+ALTER TABLE stock_opname_items ADD COLUMN IF NOT EXISTS expiry_date DATE;
@@ -0,0 +1,11 @@
+-- Migration: Drop variant_id from purchase_order_items and stock_opname_items
+-- Description: Rollback item PO dan hitungan opname per varian. Gagal jika masih ada
+-- produk yang tercatat lebih dari sekali dalam satu PO atau sesi opname
+
+DROP INDEX IF EXISTS idx_stock_opname_items_line;
+ALTER TABLE stock_opname_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE stock_opname_items ADD CONSTRAINT stock_opname_items_opname_id_product_id_key UNIQUE (opname_id, product_id);
+
+DROP INDEX IF EXISTS idx_purchase_order_items_line;
+ALTER TABLE purchase_order_items DROP COLUMN IF EXISTS variant_id;
+ALTER TABLE purchase_order_items ADD CONSTRAINT purchase_order_items_purchase_order_id_product_id_key UNIQUE (purchase_order_id, product_id);
@@ -0,0 +1,11 @@
+-- Migration: Add variant_id to purchase_order_items and stock_opname_items
+-- Description: Item PO dan hitungan opname produk bervarian dicatat per varian,
+-- sehingga satu produk boleh muncul sekali untuk setiap variannya
+
+ALTER TABLE purchase_order_items ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE RESTRICT;
+ALTER TABLE purchase_order_items DROP CONSTRAINT IF EXISTS purchase_order_items_purchase_order_id_product_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_purchase_order_items_line ON purchase_order_items(purchase_order_id, product_id, COALESCE(variant_id, 0));
+
+ALTER TABLE stock_opname_items ADD COLUMN IF NOT EXISTS variant_id INT REFERENCES product_variants(id) ON DELETE CASCADE;
+ALTER TABLE stock_opname_items DROP CONSTRAINT IF EXISTS stock_opname_items_opname_id_product_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_opname_items_line ON stock_opname_items(opname_id, product_id, COALESCE(variant_id, 0));
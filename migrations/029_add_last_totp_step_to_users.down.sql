@@ -0,0 +1,4 @@
+-- Migration: Drop last_totp_step from users
+-- Description: Rollback untuk menghapus periode TOTP terakhir yang diterima
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_totp_step;
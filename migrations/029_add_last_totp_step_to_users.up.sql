@@ -0,0 +1,5 @@
+-- Migration: Add last_totp_step to users
+-- Description: Periode TOTP terakhir yang diterima per user, supaya kode TOTP
+-- yang sama tidak bisa dipakai ulang selama masih berlaku
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_totp_step BIGINT;
@@ -1,7 +1,52 @@
 package model
 
+import "time"
+
+// Category bisa bertingkat lewat ParentID; nil berarti kategori root.
+// ParentID diabaikan saat update, pemindahan kategori harus lewat MoveCategoryRequest.
+// Kategori yang diarsipkan (ArchivedAt terisi) tidak muncul di list maupun pohon kategori
 type Category struct {
-	ID          int    `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	ParentID    *int       `json:"parent_id"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+}
+
+// CategoryNode adalah satu node di pohon kategori. ProductCount dan StockValue
+// hanya menghitung produk yang langsung ada di kategori ini, sedangkan
+// TotalProductCount dan TotalStockValue ikut menghitung semua turunannya.
+// StockValue adalah nilai persediaan berdasarkan HPP (stock x cost_price)
+type CategoryNode struct {
+	ID                int            `json:"id"`
+	Name              string         `json:"name"`
+	Description       string         `json:"description"`
+	ParentID          *int           `json:"parent_id"`
+	ProductCount      int            `json:"product_count"`
+	StockValue        int            `json:"stock_value"`
+	TotalProductCount int            `json:"total_product_count"`
+	TotalStockValue   int            `json:"total_stock_value"`
+	Children          []CategoryNode `json:"children"`
+}
+
+// MoveCategoryRequest memindahkan kategori beserta seluruh sub-kategorinya.
+// ParentID nil menjadikannya kategori root
+type MoveCategoryRequest struct {
+	ParentID *int `json:"parent_id"`
+}
+
+// Mode penghapusan kategori
+const (
+	// CategoryDeleteRestrict menolak penghapusan jika kategori masih punya produk atau sub-kategori
+	CategoryDeleteRestrict = "restrict"
+	// CategoryDeleteReassign memindahkan produk dan sub-kategori ke TargetID lalu menghapus kategori
+	CategoryDeleteReassign = "reassign"
+	// CategoryDeleteArchive mengarsipkan kategori beserta sub-kategorinya; produk tetap di kategorinya
+	CategoryDeleteArchive = "archive"
+)
+
+// DeleteCategoryRequest berisi mode penghapusan kategori. TargetID wajib untuk mode reassign
+type DeleteCategoryRequest struct {
+	Mode     string
+	TargetID int
 }
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// ModifierGroup adalah kelompok pilihan tambahan untuk sebuah produk, misalnya
+// "Level Gula" atau "Topping". Group required wajib dipilih minimal MinSelect
+// modifier, dan MaxSelect membatasi jumlah pilihan per baris checkout
+type ModifierGroup struct {
+	ID        int        `json:"id"`
+	ProductID int        `json:"product_id"`
+	Name      string     `json:"name" validate:"required"`
+	Required  bool       `json:"required"`
+	MinSelect int        `json:"min_select" validate:"min=0"`
+	MaxSelect int        `json:"max_select" validate:"min=0"`
+	Modifiers []Modifier `json:"modifiers" validate:"required,min=1,dive"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Modifier adalah satu pilihan di dalam group. PriceDelta ditambahkan ke harga
+// satuan produk saat modifier dipilih
+type Modifier struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name" validate:"required"`
+	PriceDelta int    `json:"price_delta" validate:"min=0"`
+}
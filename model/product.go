@@ -1,19 +1,81 @@
 package model
 
+import "time"
+
 type Product struct {
-	ID         int      `json:"id"`
-	Name       string   `json:"name"`
-	Price      int      `json:"price"`
-	Stock      int      `json:"stock"`
-	CategoryID int      `json:"category_id,omitempty"`
-	Category   *Category `json:"category,omitempty"`
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Price       int       `json:"price"`
+	CostPrice   int       `json:"cost_price"`
+	Stock       int       `json:"stock"`
+	MinStock    int       `json:"min_stock"`
+	TrackExpiry bool      `json:"track_expiry"`
+	BaseUnit    string    `json:"base_unit"`
+	CategoryID  int       `json:"category_id,omitempty"`
+	Category    *Category `json:"category,omitempty"`
+	SKU         string    `json:"sku,omitempty"`
+	Barcodes    []string  `json:"barcodes,omitempty"`
+	// ArchivedAt terisi untuk produk yang sudah dihapus (soft delete), dan diabaikan saat create/update
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// Variants, ModifierGroups, dan Units hanya diisi pada detail produk, dan diabaikan saat create/update
+	Variants       []ProductVariant `json:"variants,omitempty"`
+	ModifierGroups []ModifierGroup  `json:"modifier_groups,omitempty"`
+	Units          []ProductUnit    `json:"units,omitempty"`
+}
+
+// UpdateProductRequest adalah body untuk mengubah produk. MinStock dan
+// TrackExpiry nil berarti tidak diubah, begitu juga BaseUnit kosong dan
+// Barcodes nil. HPP (cost_price) tidak bisa diubah lewat sini, karena hanya
+// berubah lewat barang masuk dengan harga beli
+type UpdateProductRequest struct {
+	Name        string   `json:"name"`
+	Price       int      `json:"price"`
+	MinStock    *int     `json:"min_stock"`
+	TrackExpiry *bool    `json:"track_expiry"`
+	BaseUnit    string   `json:"base_unit"`
+	CategoryID  int      `json:"category_id"`
+	SKU         string   `json:"sku"`
+	Barcodes    []string `json:"barcodes"`
 }
 
 type ProductWithCategory struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	Price        int    `json:"price"`
-	Stock        int    `json:"stock"`
-	CategoryID   int    `json:"category_id"`
-	CategoryName string `json:"category_name"`
+	ID             int              `json:"id"`
+	Name           string           `json:"name"`
+	Price          int              `json:"price"`
+	CostPrice      int              `json:"cost_price"`
+	Stock          int              `json:"stock"`
+	MinStock       int              `json:"min_stock"`
+	TrackExpiry    bool             `json:"track_expiry"`
+	BaseUnit       string           `json:"base_unit"`
+	CategoryID     int              `json:"category_id"`
+	CategoryName   string           `json:"category_name"`
+	SKU            string           `json:"sku,omitempty"`
+	Barcodes       []string         `json:"barcodes"`
+	Variants       []ProductVariant `json:"variants"`
+	ModifierGroups []ModifierGroup  `json:"modifier_groups"`
+	Units          []ProductUnit    `json:"units"`
+	ArchivedAt     *time.Time       `json:"archived_at,omitempty"`
+}
+
+// ProductFilter berisi parameter filter, sorting, dan paginasi untuk list produk
+type ProductFilter struct {
+	Name       string
+	CategoryID int
+	MinPrice   *int
+	MaxPrice   *int
+	InStock    bool
+	// Archived true berarti hanya produk yang diarsipkan, false hanya produk aktif
+	Archived bool
+	Sort     string
+	Order    string
+	Limit    int
+	Offset   int
+}
+
+// LowStockAlert dikirim saat stok produk turun ke atau di bawah MinStock (reorder point)
+type LowStockAlert struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Stock       int    `json:"stock"`
+	MinStock    int    `json:"min_stock"`
 }
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// ProductLot adalah satu batch stok dari produk yang dilacak kedaluwarsanya
+// (track_expiry). ExpiryDate berformat YYYY-MM-DD, dan DaysLeft negatif berarti
+// lot sudah kedaluwarsa. Lot kedaluwarsa tidak bisa dijual dan hanya bisa
+// dikeluarkan lewat movement waste atau adjustment
+type ProductLot struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	ProductName string    `json:"product_name,omitempty"`
+	VariantID   *int      `json:"variant_id,omitempty"`
+	VariantName string    `json:"variant_name,omitempty"`
+	ExpiryDate  string    `json:"expiry_date"`
+	ReceivedAt  time.Time `json:"received_at"`
+	Quantity    int       `json:"quantity"`
+	Remaining   int       `json:"remaining"`
+	UnitCost    *int      `json:"unit_cost,omitempty"`
+	Reference   string    `json:"reference,omitempty"`
+	DaysLeft    int       `json:"days_left"`
+	Expired     bool      `json:"expired"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// ProductUnit adalah satuan alternatif sebuah produk. Factor adalah jumlah
+// satuan dasar dalam satu satuan ini (mis. karton = 24 pcs). Price nil berarti
+// harga satuan ini adalah harga produk dikali Factor
+type ProductUnit struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name" validate:"required,max=32"`
+	Factor    int    `json:"factor" validate:"required,gt=0"`
+	Price     *int   `json:"price,omitempty" validate:"omitempty,min=0"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// ProductVariant adalah varian dari sebuah produk induk, misalnya ukuran atau warna.
+// Price nil berarti varian memakai harga produk induk
+type ProductVariant struct {
+	ID        int               `json:"id"`
+	ProductID int               `json:"product_id"`
+	Name      string            `json:"name" validate:"required"`
+	Options   map[string]string `json:"options,omitempty"`
+	SKU       string            `json:"sku,omitempty"`
+	Price     *int              `json:"price,omitempty" validate:"omitempty,min=0"`
+	Stock     int               `json:"stock"`
+	CreatedAt time.Time         `json:"created_at"`
+}
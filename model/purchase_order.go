@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+// Status purchase order
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderSent              = "sent"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderCancelled         = "cancelled"
+)
+
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	SupplierID   int                 `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name,omitempty"`
+	Status       string              `json:"status"`
+	Note         string              `json:"note,omitempty"`
+	TotalCost    int                 `json:"total_cost"`
+	CreatedBy    *int                `json:"created_by,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	Items        []PurchaseOrderItem `json:"items,omitempty"`
+}
+
+// PurchaseOrderItem dipesan dalam satuan beli Unit. Quantity, ReceivedQty, dan
+// UnitCost mengikuti satuan tersebut; saat diterima, stok bertambah sebanyak
+// Quantity x UnitFactor satuan dasar. VariantID terisi untuk item produk bervarian
+type PurchaseOrderItem struct {
+	ID          int    `json:"id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	VariantID   *int   `json:"variant_id,omitempty"`
+	VariantName string `json:"variant_name,omitempty"`
+	Unit        string `json:"unit"`
+	UnitFactor  int    `json:"unit_factor"`
+	Quantity    int    `json:"quantity"`
+	UnitCost    int    `json:"unit_cost"`
+	ReceivedQty int    `json:"received_qty"`
+}
+
+// PurchaseOrderItemRequest adalah satu item PO. Unit kosong berarti satuan dasar
+// produk. VariantID wajib diisi untuk produk yang punya varian
+type PurchaseOrderItemRequest struct {
+	ProductID int    `json:"product_id" validate:"required"`
+	VariantID int    `json:"variant_id,omitempty"`
+	Unit      string `json:"unit"`
+	Quantity  int    `json:"quantity" validate:"required,gt=0"`
+	UnitCost  int    `json:"unit_cost" validate:"min=0"`
+}
+
+type CreatePurchaseOrderRequest struct {
+	SupplierID int                        `json:"supplier_id" validate:"required"`
+	Note       string                     `json:"note"`
+	Items      []PurchaseOrderItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// ReceiveItemRequest adalah satu item yang diterima. VariantID harus sama
+// dengan item PO-nya, dan ExpiryDate (YYYY-MM-DD) wajib untuk produk track_expiry
+type ReceiveItemRequest struct {
+	ProductID  int    `json:"product_id" validate:"required"`
+	VariantID  int    `json:"variant_id,omitempty"`
+	Quantity   int    `json:"quantity" validate:"required,gt=0"`
+	ExpiryDate string `json:"expiry_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// ReceivePurchaseOrderRequest mencatat barang yang datang. Boleh dikirim
+// beberapa kali untuk pengiriman bertahap
+type ReceivePurchaseOrderRequest struct {
+	Reference string               `json:"reference"`
+	Items     []ReceiveItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// PurchaseOrderFilter berisi filter untuk list purchase order
+type PurchaseOrderFilter struct {
+	Status     string
+	SupplierID int
+}
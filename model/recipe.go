@@ -0,0 +1,28 @@
+package model
+
+// RecipeIngredient adalah satu baris resep: bahan yang dipakai dan jumlahnya
+// untuk membuat satu unit produk komposit
+type RecipeIngredient struct {
+	IngredientID   int    `json:"ingredient_id" validate:"required"`
+	IngredientName string `json:"ingredient_name,omitempty"`
+	Quantity       int    `json:"quantity" validate:"required,min=1"`
+	CostPrice      int    `json:"cost_price"`
+	Stock          int    `json:"stock"`
+}
+
+// Recipe adalah bill of materials sebuah produk komposit. UnitCost adalah HPP
+// satu unit dari HPP bahan-bahannya, dan Available adalah jumlah unit yang
+// masih bisa dibuat dari stok bahan saat ini
+type Recipe struct {
+	ProductID   int                `json:"product_id"`
+	ProductName string             `json:"product_name"`
+	Ingredients []RecipeIngredient `json:"ingredients"`
+	UnitCost    int                `json:"unit_cost"`
+	Available   int                `json:"available"`
+}
+
+// SetRecipeRequest mengganti seluruh resep produk. Daftar kosong menghapus
+// resep sehingga produk kembali menjadi produk biasa
+type SetRecipeRequest struct {
+	Ingredients []RecipeIngredient `json:"ingredients" validate:"dive"`
+}
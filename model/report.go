@@ -0,0 +1,30 @@
+package model
+
+// ProfitLine adalah baris laporan laba kotor per produk atau per kategori
+type ProfitLine struct {
+	ID          int     `json:"id"`
+	ProductID   int     `json:"product_id,omitempty"`
+	Name        string  `json:"name"`
+	QtySold     int     `json:"qty_sold"`
+	Revenue     int     `json:"revenue"`
+	COGS        int     `json:"cogs"`
+	GrossProfit int     `json:"gross_profit"`
+	GrossMargin float64 `json:"gross_margin"`
+}
+
+// ProfitReport berisi pendapatan, HPP (COGS), laba kotor, dan margin (dalam persen)
+// untuk rentang tanggal tertentu
+type ProfitReport struct {
+	StartDate   string       `json:"start_date,omitempty"`
+	EndDate     string       `json:"end_date,omitempty"`
+	Revenue     int          `json:"revenue"`
+	COGS        int          `json:"cogs"`
+	GrossProfit int          `json:"gross_profit"`
+	GrossMargin float64      `json:"gross_margin"`
+	Products    []ProfitLine `json:"products"`
+	Variants    []ProfitLine `json:"variants"`
+	Categories  []ProfitLine `json:"categories"`
+	// Modifiers adalah pendapatan dari modifier berbayar, yang juga sudah
+	// termasuk di pendapatan produknya
+	Modifiers []ProfitLine `json:"modifiers"`
+}
@@ -9,22 +9,53 @@ type Response struct {
 	Status  string      `json:"status"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	Meta    interface{} `json:"meta,omitempty"`
+}
+
+// Pagination adalah metadata untuk response list yang dipaginasi
+type Pagination struct {
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	// NextOffset berisi offset untuk halaman berikutnya, null jika sudah halaman terakhir
+	NextOffset *int `json:"next_offset"`
+}
+
+func NewPagination(total, limit, offset int) Pagination {
+	p := Pagination{Total: total, Limit: limit, Offset: offset}
+	if next := offset + limit; next < total {
+		p.NextOffset = &next
+	}
+	return p
 }
 
 func JSON(w http.ResponseWriter, code int, status string, message string, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	json.NewEncoder(w).Encode(Response{
+	writeJSON(w, code, Response{
 		Status:  status,
 		Message: message,
 		Data:    data,
 	})
 }
 
+func writeJSON(w http.ResponseWriter, code int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
+}
+
 func Success(w http.ResponseWriter, code int, message string, data interface{}) {
 	JSON(w, code, "ok", message, data)
 }
 
+func SuccessWithMeta(w http.ResponseWriter, code int, message string, data interface{}, meta interface{}) {
+	writeJSON(w, code, Response{
+		Status:  "ok",
+		Message: message,
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
 func Error(w http.ResponseWriter, code int, message string) {
 	JSON(w, code, "error", message, nil)
 }
@@ -0,0 +1,58 @@
+package model
+
+const (
+	RoleOwner   = "owner"
+	RoleAdmin   = "admin"
+	RoleCashier = "cashier"
+)
+
+// Permission yang dicek per route oleh middleware
+const (
+	PermCatalogRead  = "catalog:read"
+	PermCatalogWrite = "catalog:write"
+	PermCheckout     = "checkout"
+	PermReportRead   = "report:read"
+	PermUserManage   = "user:manage"
+	PermUserRole     = "user:role"
+	PermAuditRead    = "audit:read"
+	PermProfile      = "profile:manage"
+	PermTerminal     = "terminal:manage"
+	PermAPIKey       = "apikey:manage"
+	PermTwoFactor    = "2fa:manage"
+	PermInventory    = "inventory:manage"
+)
+
+// POSScopes adalah permission yang boleh dipakai token hasil PIN login di terminal kasir
+var POSScopes = []string{PermCatalogRead, PermCheckout}
+
+// APIKeyScopes adalah permission yang boleh diberikan ke API key
+var APIKeyScopes = []string{PermCatalogRead, PermCatalogWrite, PermCheckout, PermReportRead, PermInventory}
+
+var rolePermissions = map[string][]string{
+	RoleOwner: {
+		PermCatalogRead, PermCatalogWrite, PermCheckout,
+		PermReportRead, PermUserManage, PermUserRole, PermAuditRead,
+		PermProfile, PermTerminal, PermAPIKey, PermTwoFactor, PermInventory,
+	},
+	RoleAdmin: {
+		PermCatalogRead, PermCatalogWrite, PermCheckout, PermUserManage,
+		PermProfile, PermTerminal, PermAPIKey, PermTwoFactor, PermInventory,
+	},
+	RoleCashier: {
+		PermCatalogRead, PermCheckout, PermProfile, PermTwoFactor,
+	},
+}
+
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role, perm string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
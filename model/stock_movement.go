@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Jenis pergerakan stok
+const (
+	MovementSale       = "sale"
+	MovementRestock    = "restock"
+	MovementAdjustment = "adjustment"
+	MovementReturn     = "return"
+	MovementWaste      = "waste"
+)
+
+// StockMovement adalah satu baris ledger stok yang bersifat append-only.
+// Quantity bertanda: positif menambah stok, negatif mengurangi stok.
+// Untuk produk bervarian, VariantID terisi dan StockAfter adalah stok varian.
+// UnitCost adalah harga beli per unit untuk movement yang menambah stok; jika
+// diisi, HPP produk dihitung ulang dengan rata-rata tertimbang.
+// Untuk produk track_expiry, movement yang menambah stok wajib membawa
+// ExpiryDate dan membuat lot baru (LotID)
+type StockMovement struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"product_id"`
+	VariantID  *int      `json:"variant_id,omitempty"`
+	Type       string    `json:"type"`
+	Quantity   int       `json:"quantity"`
+	StockAfter int       `json:"stock_after"`
+	UnitCost   *int      `json:"unit_cost,omitempty"`
+	LotID      *int      `json:"lot_id,omitempty"`
+	ExpiryDate string    `json:"expiry_date,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Reference  string    `json:"reference,omitempty"`
+	UserID     *int      `json:"user_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	// ProductStockAfter adalah stok produk induk setelah movement. Untuk produk
+	// tanpa varian nilainya sama dengan StockAfter
+	ProductStockAfter int `json:"-"`
+}
+
+// CreateStockMovementRequest dipakai untuk mencatat pergerakan stok manual.
+// Quantity untuk restock, return, dan waste selalu positif; arahnya ditentukan
+// dari type. Untuk adjustment, quantity adalah selisih bertanda (boleh negatif).
+// Quantity dan UnitCost mengikuti Unit (kosong berarti satuan dasar), lalu
+// dikonversi ke satuan dasar produk sebelum dicatat
+type CreateStockMovementRequest struct {
+	Type      string  `json:"type" validate:"required,oneof=restock adjustment return waste"`
+	VariantID *int    `json:"variant_id"`
+	Quantity  float64 `json:"quantity" validate:"required"`
+	Unit      string  `json:"unit"`
+	Reason    string  `json:"reason"`
+	Reference string  `json:"reference"`
+	UnitCost  *int    `json:"unit_cost" validate:"omitempty,min=0"`
+	// ExpiryDate (YYYY-MM-DD) wajib untuk barang masuk ke produk track_expiry
+	ExpiryDate string `json:"expiry_date" validate:"omitempty,datetime=2006-01-02"`
+}
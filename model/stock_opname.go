@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// Status sesi stock opname
+const (
+	OpnameOpen      = "open"
+	OpnameFinalized = "finalized"
+	OpnameCancelled = "cancelled"
+)
+
+type StockOpname struct {
+	ID          int        `json:"id"`
+	Status      string     `json:"status"`
+	Note        string     `json:"note,omitempty"`
+	StartedBy   *int       `json:"started_by,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinalizedBy *int       `json:"finalized_by,omitempty"`
+	FinalizedAt *time.Time `json:"finalized_at,omitempty"`
+}
+
+// StockOpnameItem adalah hasil hitung fisik satu produk atau satu varian.
+// SystemStock dan UnitPrice di-snapshot saat jumlah hitungan disubmit
+type StockOpnameItem struct {
+	ProductID     int       `json:"product_id"`
+	ProductName   string    `json:"product_name"`
+	VariantID     *int      `json:"variant_id,omitempty"`
+	VariantName   string    `json:"variant_name,omitempty"`
+	SystemStock   int       `json:"system_stock"`
+	CountedQty    int       `json:"counted_qty"`
+	ExpiryDate    string    `json:"expiry_date,omitempty"`
+	Variance      int       `json:"variance"`
+	UnitPrice     int       `json:"unit_price"`
+	VarianceValue int       `json:"variance_value"`
+	CountedBy     *int      `json:"counted_by,omitempty"`
+	CountedAt     time.Time `json:"counted_at"`
+}
+
+// StockOpnameSummary merangkum selisih stok. Shrinkage adalah barang yang
+// hilang (hitungan lebih kecil dari sistem), surplus sebaliknya
+type StockOpnameSummary struct {
+	TotalItems        int `json:"total_items"`
+	ItemsWithVariance int `json:"items_with_variance"`
+	ShrinkageQty      int `json:"shrinkage_qty"`
+	ShrinkageValue    int `json:"shrinkage_value"`
+	SurplusQty        int `json:"surplus_qty"`
+	SurplusValue      int `json:"surplus_value"`
+	NetVarianceValue  int `json:"net_variance_value"`
+}
+
+type StockOpnameDetail struct {
+	StockOpname
+	Items   []StockOpnameItem  `json:"items"`
+	Summary StockOpnameSummary `json:"summary"`
+}
+
+type StartStockOpnameRequest struct {
+	Note string `json:"note"`
+}
+
+// StockCount adalah hitungan satu produk. Produk bervarian dihitung per varian,
+// jadi VariantID wajib diisi. ExpiryDate (YYYY-MM-DD) wajib untuk produk
+// track_expiry yang hitungannya melebihi stok sistem, karena surplusnya
+// dicatat sebagai lot baru saat finalisasi
+type StockCount struct {
+	ProductID  int    `json:"product_id" validate:"required"`
+	VariantID  int    `json:"variant_id,omitempty"`
+	CountedQty int    `json:"counted_qty" validate:"min=0"`
+	ExpiryDate string `json:"expiry_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// SubmitStockCountRequest boleh dikirim berkali-kali selama sesi masih open.
+// Produk (atau varian) yang sudah pernah dihitung akan ditimpa dengan hitungan terbaru
+type SubmitStockCountRequest struct {
+	Items []StockCount `json:"items" validate:"required,min=1,dive"`
+}
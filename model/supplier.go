@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+type Supplier struct {
+	ID           int       `json:"id"`
+	Name         string    `json:"name" validate:"required"`
+	ContactName  string    `json:"contact_name"`
+	Phone        string    `json:"phone"`
+	Email        string    `json:"email" validate:"omitempty,email"`
+	Address      string    `json:"address"`
+	PaymentTerms string    `json:"payment_terms"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type Terminal struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	KeyHash   string    `json:"-"`
+}
+
+type CreateTerminalRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// CreateTerminalResponse berisi key terminal dalam bentuk plain text. Key hanya
+// ditampilkan sekali saat terminal didaftarkan, setelah itu hanya hash-nya yang disimpan
+type CreateTerminalResponse struct {
+	Terminal *Terminal `json:"terminal"`
+	Key      string    `json:"key"`
+}
+
+type PinLoginRequest struct {
+	UserID int    `json:"user_id" validate:"required"`
+	PIN    string `json:"pin" validate:"required,numeric,min=4,max=6"`
+}
+
+type SetPinRequest struct {
+	Password string `json:"password" validate:"required"`
+	PIN      string `json:"pin" validate:"required,numeric,min=4,max=6"`
+}
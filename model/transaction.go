@@ -7,6 +7,8 @@ type Transaction struct {
 	TotalAmount int                 `json:"total_amount"`
 	CreatedAt   time.Time           `json:"created_at"`
 	Details     []TransactionDetail `json:"details"`
+	// LowStockAlerts berisi produk yang stoknya turun ke batas minimum karena transaksi ini
+	LowStockAlerts []LowStockAlert `json:"low_stock_alerts,omitempty"`
 }
 
 type TransactionDetail struct {
@@ -14,13 +16,42 @@ type TransactionDetail struct {
 	TransactionID int    `json:"transaction_id"`
 	ProductID     int    `json:"product_id"`
 	ProductName   string `json:"product_name,omitempty"`
+	VariantID     *int   `json:"variant_id,omitempty"`
+	VariantName   string `json:"variant_name,omitempty"`
 	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	// Unit dan UnitQuantity adalah satuan dan jumlah yang diinput saat checkout.
+	// Quantity selalu dalam satuan dasar produk
+	Unit         string  `json:"unit,omitempty"`
+	UnitQuantity float64 `json:"unit_quantity,omitempty"`
+	Subtotal     int     `json:"subtotal"`
+	// Modifiers adalah modifier yang dipilih; harganya sudah termasuk di Subtotal
+	Modifiers []TransactionDetailModifier `json:"modifiers,omitempty"`
+	// UnitCost adalah snapshot HPP produk saat checkout, dipakai untuk laporan laba
+	UnitCost int `json:"-"`
 }
 
+// TransactionDetailModifier adalah snapshot modifier yang dipilih pada satu baris
+// transaksi. Nama dan harga disimpan apa adanya supaya struk lama tidak berubah
+// walaupun modifier-nya diubah atau dihapus
+type TransactionDetailModifier struct {
+	ModifierID *int   `json:"modifier_id,omitempty"`
+	GroupName  string `json:"group_name"`
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+}
+
+// CheckoutItem menunjuk produk lewat ProductID atau Barcode hasil scan.
+// VariantID wajib diisi untuk produk yang punya varian, dan ModifierIDs berisi
+// modifier yang dipilih untuk baris ini (berlaku untuk setiap unit).
+// Quantity boleh pecahan (mis. 0.25 kg) dalam Unit; Unit kosong berarti
+// satuan dasar produk
 type CheckoutItem struct {
-	ProductID int `json:"product_id"`
-	Quantity  int `json:"quantity"`
+	ProductID   int     `json:"product_id,omitempty"`
+	VariantID   int     `json:"variant_id,omitempty"`
+	Barcode     string  `json:"barcode,omitempty"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit,omitempty"`
+	ModifierIDs []int   `json:"modifier_ids,omitempty"`
 }
 
 type CheckoutRequest struct {
@@ -30,6 +61,10 @@ type CheckoutRequest struct {
 type SalesSummary struct {
 	TotalRevenue   int `json:"total_revenue"`
 	TotalTransaksi int `json:"total_transaksi"`
+	TotalCOGS      int `json:"total_cogs"`
+	GrossProfit    int `json:"gross_profit"`
+	// GrossMargin adalah laba kotor dibagi pendapatan, dalam persen
+	GrossMargin    float64 `json:"gross_margin"`
 	ProdukTerlaris struct {
 		Nama       string `json:"nama"`
 		QtyTerjual int    `json:"qty_terjual"`
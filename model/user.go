@@ -1,13 +1,48 @@
 package model
 
+import "time"
+
 type User struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"-"`
+	ID                  int        `json:"id"`
+	Name                string     `json:"name"`
+	Email               string     `json:"email"`
+	Role                string     `json:"role"`
+	Active              bool       `json:"active"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+	TwoFactorEnabled    bool       `json:"two_factor_enabled"`
+	FailedLoginAttempts int        `json:"-"`
+	Password            string     `json:"-"`
+	PinHash             string     `json:"-"`
+	TOTPSecret          string     `json:"-"`
 }
 
 type UpdateUserRequest struct {
 	Name  string `json:"name" validate:"required"`
 	Email string `json:"email" validate:"required,email"`
 }
+
+type MeResponse struct {
+	User  *User     `json:"user"`
+	Token TokenInfo `json:"token"`
+}
+
+// TokenInfo berisi hasil introspeksi access token yang sedang dipakai
+type TokenInfo struct {
+	UserID     int       `json:"user_id"`
+	Role       string    `json:"role"`
+	Scopes     []string  `json:"scopes,omitempty"`
+	TerminalID int       `json:"terminal_id,omitempty"`
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type CreateUserRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
+	Role     string `json:"role" validate:"required,oneof=owner admin cashier"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner admin cashier"`
+}
@@ -0,0 +1,112 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message adalah pesan yang dikirim notifier. Event dan Data opsional, dipakai
+// oleh penerima mesin (misalnya webhook) untuk membedakan jenis notifikasi
+type Message struct {
+	To      string `json:"to,omitempty"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	Event   string `json:"event,omitempty"`
+	Data    any    `json:"data,omitempty"`
+}
+
+// Notifier mengirim pesan ke user (email, WhatsApp, dll). Implementasi bisa diganti
+// lewat config tanpa mengubah service yang memakainya
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// New membuat notifier sesuai driver dari config: "log" (default), "file", atau "webhook"
+func New(driver, filePath, webhookURL string) (Notifier, error) {
+	switch driver {
+	case "", "log":
+		return &LogNotifier{}, nil
+	case "file":
+		if filePath == "" {
+			return nil, fmt.Errorf("notifier file path is required for driver %q", driver)
+		}
+		return NewFileNotifier(filePath), nil
+	case "webhook":
+		if webhookURL == "" {
+			return nil, fmt.Errorf("notifier webhook url is required for driver %q", driver)
+		}
+		return NewWebhookNotifier(webhookURL), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier driver %q", driver)
+	}
+}
+
+// LogNotifier hanya menulis pesan ke log aplikasi, cocok untuk development
+type LogNotifier struct{}
+
+func (n *LogNotifier) Send(msg Message) error {
+	log.Printf("[notifier] to=%s subject=%q body=%q", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier menambahkan pesan ke sebuah file lokal, cocok untuk development
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Send(msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "[%s] To: %s\nSubject: %s\n\n%s\n\n", time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+// WebhookNotifier mengirim pesan sebagai JSON lewat HTTP POST ke URL yang dikonfigurasi
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Send(msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/model"
+
+	"github.com/lib/pq"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+func (repo *APIKeyRepository) GetAll() ([]model.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, scopes, expires_at, last_used_at, revoked_at, created_by, created_at
+		FROM api_keys
+		ORDER BY id`
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]model.APIKey, 0)
+	for rows.Next() {
+		var k model.APIKey
+		err := rows.Scan(&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedBy, &k.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+func (repo *APIKeyRepository) Create(k *model.APIKey) error {
+	query := `
+		INSERT INTO api_keys (name, key_prefix, key_hash, scopes, expires_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+	return repo.db.QueryRow(query, k.Name, k.Prefix, k.KeyHash, pq.Array(k.Scopes), k.ExpiresAt, k.CreatedBy).Scan(&k.ID, &k.CreatedAt)
+}
+
+func (repo *APIKeyRepository) GetByKeyHash(keyHash string) (*model.APIKey, error) {
+	query := `
+		SELECT id, name, key_prefix, scopes, expires_at, last_used_at, revoked_at, created_by, created_at
+		FROM api_keys
+		WHERE key_hash = $1`
+
+	var k model.APIKey
+	err := repo.db.QueryRow(query, keyHash).Scan(&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedBy, &k.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("api key not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
+
+// TouchLastUsed memperbarui last_used_at paling sering sekali per menit
+// supaya tidak ada write ke database di setiap request
+func (repo *APIKeyRepository) TouchLastUsed(id int) error {
+	query := `
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`
+	_, err := repo.db.Exec(query, id)
+	return err
+}
+
+func (repo *APIKeyRepository) Revoke(id int) error {
+	query := "UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL"
+	result, err := repo.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("api key not found or already revoked")
+	}
+
+	return nil
+}
@@ -3,7 +3,9 @@ package repositories
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"kasir-api/model"
+	"time"
 )
 
 type AuthRepository struct {
@@ -14,16 +16,51 @@ func NewAuthRepository(db *sql.DB) *AuthRepository {
 	return &AuthRepository{db: db}
 }
 
-func (repo *AuthRepository) CreateUser(u *model.User) error {
-	query := "INSERT INTO users(name, email, password) VALUES($1, $2, $3) RETURNING id"
-	return repo.db.QueryRow(query, u.Name, u.Email, u.Password).Scan(&u.ID)
+// RegisterUser menyimpan user hasil registrasi publik. Tabel users dikunci dari
+// insert lain selama transaksi, sehingga assign dipanggil dengan jumlah user yang
+// tidak berubah sampai user ini tersimpan. assign menentukan role user (atau
+// menolak registrasi) berdasarkan jumlah tersebut
+func (repo *AuthRepository) RegisterUser(u *model.User, assign func(count int) error) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		return err
+	}
+	if err := assign(count); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO users(name, email, password, role) VALUES($1, $2, $3, $4) RETURNING id, active"
+	if err := tx.QueryRow(query, u.Name, u.Email, u.Password, u.Role).Scan(&u.ID, &u.Active); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// authUserColumns adalah kolom users yang dibutuhkan untuk proses autentikasi, dipakai bersama scanAuthUser
+const authUserColumns = `id, name, email, role, active, password, COALESCE(pin_hash, ''), failed_login_attempts, locked_until,
+	COALESCE(totp_secret, ''), totp_enabled`
+
+func scanAuthUser(row *sql.Row, u *model.User) error {
+	return row.Scan(&u.ID, &u.Name, &u.Email, &u.Role, &u.Active, &u.Password, &u.PinHash, &u.FailedLoginAttempts, &u.LockedUntil,
+		&u.TOTPSecret, &u.TwoFactorEnabled)
 }
 
 func (repo *AuthRepository) GetUserByEmail(email string) (*model.User, error) {
 	var u model.User
-	query := "SELECT id, name, email, password FROM users WHERE email = $1"
+	query := "SELECT " + authUserColumns + " FROM users WHERE email = $1"
 
-	err := repo.db.QueryRow(query, email).Scan(&u.ID, &u.Name, &u.Email, &u.Password)
+	err := scanAuthUser(repo.db.QueryRow(query, email), &u)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errors.New("User with the given Email is not found")
@@ -33,3 +70,309 @@ func (repo *AuthRepository) GetUserByEmail(email string) (*model.User, error) {
 
 	return &u, nil
 }
+
+func (repo *AuthRepository) GetUserByID(id int) (*model.User, error) {
+	var u model.User
+	query := "SELECT " + authUserColumns + " FROM users WHERE id = $1"
+
+	err := scanAuthUser(repo.db.QueryRow(query, id), &u)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+
+	return &u, nil
+}
+
+func (repo *AuthRepository) CreateRefreshToken(t *model.RefreshToken) error {
+	query := "INSERT INTO refresh_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id"
+	return repo.db.QueryRow(query, t.UserID, t.TokenHash, t.ExpiresAt).Scan(&t.ID)
+}
+
+func (repo *AuthRepository) GetRefreshToken(tokenHash string) (*model.RefreshToken, error) {
+	var t model.RefreshToken
+	query := "SELECT id, user_id, token_hash, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = $1"
+
+	err := repo.db.QueryRow(query, tokenHash).Scan(&t.ID, &t.UserID, &t.TokenHash, &t.ExpiresAt, &t.RevokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("refresh token not found")
+		}
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// RevokeRefreshToken menandai token sebagai revoked. Mengembalikan false jika token
+// sudah di-revoke sebelumnya (misal dipakai dua kali secara bersamaan)
+func (repo *AuthRepository) RevokeRefreshToken(id int) (bool, error) {
+	query := "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL"
+	result, err := repo.db.Exec(query, id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (repo *AuthRepository) RevokeAllRefreshTokens(userID int) error {
+	query := "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL"
+	_, err := repo.db.Exec(query, userID)
+	return err
+}
+
+func (repo *AuthRepository) UpdatePassword(userID int, hashed string) error {
+	query := "UPDATE users SET password = $1 WHERE id = $2"
+	result, err := repo.db.Exec(query, hashed, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+func (repo *AuthRepository) UpdatePin(userID int, hashed string) error {
+	query := "UPDATE users SET pin_hash = $1 WHERE id = $2"
+	result, err := repo.db.Exec(query, hashed, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+func (repo *AuthRepository) CreatePasswordReset(p *model.PasswordReset) error {
+	query := "INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3) RETURNING id"
+	return repo.db.QueryRow(query, p.UserID, p.TokenHash, p.ExpiresAt).Scan(&p.ID)
+}
+
+func (repo *AuthRepository) GetPasswordReset(tokenHash string) (*model.PasswordReset, error) {
+	var p model.PasswordReset
+	query := "SELECT id, user_id, token_hash, expires_at, used_at FROM password_resets WHERE token_hash = $1"
+
+	err := repo.db.QueryRow(query, tokenHash).Scan(&p.ID, &p.UserID, &p.TokenHash, &p.ExpiresAt, &p.UsedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("password reset token not found")
+		}
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// UsePasswordResets menandai semua token reset milik user sebagai terpakai.
+// Mengembalikan false jika token dengan id tersebut ternyata sudah terpakai
+func (repo *AuthRepository) UsePasswordResets(userID, id int) (bool, error) {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE password_resets SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND used_at IS NULL", id)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec("UPDATE password_resets SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND used_at IS NULL", userID)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// IncrementFailedLogins menambah counter gagal login dan mengembalikan nilai terbarunya
+func (repo *AuthRepository) IncrementFailedLogins(userID int) (int, error) {
+	var attempts int
+	query := "UPDATE users SET failed_login_attempts = failed_login_attempts + 1 WHERE id = $1 RETURNING failed_login_attempts"
+	err := repo.db.QueryRow(query, userID).Scan(&attempts)
+	return attempts, err
+}
+
+func (repo *AuthRepository) LockUser(userID int, until time.Time) error {
+	_, err := repo.db.Exec("UPDATE users SET locked_until = $1 WHERE id = $2", until, userID)
+	return err
+}
+
+func (repo *AuthRepository) ResetFailedLogins(userID int) error {
+	_, err := repo.db.Exec("UPDATE users SET failed_login_attempts = 0, locked_until = NULL WHERE id = $1", userID)
+	return err
+}
+
+func (repo *AuthRepository) CreateLoginAttempt(a *model.LoginAttempt) error {
+	query := "INSERT INTO login_attempts (email, ip_address, success, reason) VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id, created_at"
+	return repo.db.QueryRow(query, a.Email, a.IPAddress, a.Success, a.Reason).Scan(&a.ID, &a.CreatedAt)
+}
+
+// CountFailedLoginsByIP menghitung percobaan login gagal dari sebuah IP sejak waktu tertentu.
+// Request yang ditolak throttle (ip_rate_limited) tidak dihitung, supaya client
+// yang terus mencoba tidak memperpanjang window-nya sendiri tanpa batas
+func (repo *AuthRepository) CountFailedLoginsByIP(ip string, since time.Time) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM login_attempts WHERE ip_address = $1 AND success = FALSE AND created_at >= $2 AND reason IS DISTINCT FROM 'ip_rate_limited'"
+	err := repo.db.QueryRow(query, ip, since).Scan(&count)
+	return count, err
+}
+
+func (repo *AuthRepository) GetLoginAttempts(filter model.LoginAttemptFilter) ([]model.LoginAttempt, error) {
+	query := "SELECT id, email, ip_address, success, COALESCE(reason, ''), created_at FROM login_attempts WHERE 1=1"
+	args := []interface{}{}
+
+	if filter.Email != "" {
+		args = append(args, filter.Email)
+		query += fmt.Sprintf(" AND email = $%d", len(args))
+	}
+	if filter.IP != "" {
+		args = append(args, filter.IP)
+		query += fmt.Sprintf(" AND ip_address = $%d", len(args))
+	}
+	if filter.Success != nil {
+		args = append(args, *filter.Success)
+		query += fmt.Sprintf(" AND success = $%d", len(args))
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := make([]model.LoginAttempt, 0)
+	for rows.Next() {
+		var a model.LoginAttempt
+		err := rows.Scan(&a.ID, &a.Email, &a.IPAddress, &a.Success, &a.Reason, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+func (repo *AuthRepository) SetTOTPSecret(userID int, secret string) error {
+	_, err := repo.db.Exec("UPDATE users SET totp_secret = $1 WHERE id = $2", secret, userID)
+	return err
+}
+
+// EnableTOTP mengaktifkan 2FA dan mengganti semua backup code lama dengan yang baru
+func (repo *AuthRepository) EnableTOTP(userID int, backupCodeHashes []string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE users SET totp_enabled = TRUE WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM backup_codes WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range backupCodeHashes {
+		_, err = tx.Exec("INSERT INTO backup_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *AuthRepository) DisableTOTP(userID int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE users SET totp_enabled = FALSE, totp_secret = NULL WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM backup_codes WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep mencatat periode TOTP yang baru diterima. Mengembalikan false jika
+// periode tersebut (atau yang lebih baru) sudah pernah dipakai, sehingga kode
+// yang sama tidak bisa dipakai ulang
+func (repo *AuthRepository) UseTOTPStep(userID int, step int64) (bool, error) {
+	query := "UPDATE users SET last_totp_step = $1 WHERE id = $2 AND (last_totp_step IS NULL OR last_totp_step < $1)"
+	result, err := repo.db.Exec(query, step, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// UseBackupCode menandai backup code sebagai terpakai. Mengembalikan false jika
+// kode tidak ditemukan atau sudah pernah dipakai
+func (repo *AuthRepository) UseBackupCode(userID int, codeHash string) (bool, error) {
+	query := "UPDATE backup_codes SET used_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL"
+	result, err := repo.db.Exec(query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
@@ -4,6 +4,25 @@ import (
 	"database/sql"
 	"errors"
 	"kasir-api/model"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrCategoryNotFound dikembalikan saat kategori dengan ID tersebut tidak ada
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrParentCategoryNotFound dikembalikan saat parent_id menunjuk kategori yang tidak ada
+	ErrParentCategoryNotFound = errors.New("parent category not found")
+	// ErrCategoryCycle dikembalikan saat kategori dipindah ke dirinya sendiri atau ke turunannya
+	ErrCategoryCycle = errors.New("category cannot be moved under itself or its descendants")
+	// ErrCategoryHasChildren dikembalikan saat menghapus kategori yang masih punya sub-kategori
+	ErrCategoryHasChildren = errors.New("category still has sub-categories")
+	// ErrCategoryHasProducts dikembalikan saat menghapus kategori yang masih punya produk
+	ErrCategoryHasProducts = errors.New("category still has products, reassign or archive it instead")
+	// ErrCategoryArchived dikembalikan saat memakai kategori yang sudah diarsipkan sebagai parent atau memindahkannya
+	ErrCategoryArchived = errors.New("category is archived")
+	// ErrInvalidReassignTarget dikembalikan saat target reassign tidak ada, diarsipkan, atau berada di subtree kategori yang dihapus
+	ErrInvalidReassignTarget = errors.New("reassign target must be an active category outside the deleted category's subtree")
 )
 
 type CategoryRepository struct {
@@ -14,8 +33,20 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
+// categorySubtree mengembalikan subquery berisi ID kategori param beserta
+// semua turunannya. UNION (bukan UNION ALL) menjaga query tetap berhenti
+// seandainya data lama membentuk siklus
+func categorySubtree(param string) string {
+	return `WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ` + param + `
+			UNION
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree`
+}
+
 func (repo *CategoryRepository) GetAll() ([]model.Category, error) {
-	query := "SELECT id, name, description FROM categories"
+	query := "SELECT id, name, description, parent_id, archived_at FROM categories WHERE archived_at IS NULL"
 	rows, err := repo.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -25,7 +56,7 @@ func (repo *CategoryRepository) GetAll() ([]model.Category, error) {
 	categories := make([]model.Category, 0)
 	for rows.Next() {
 		var c model.Category
-		err := rows.Scan(&c.ID, &c.Name, &c.Description)
+		err := rows.Scan(&c.ID, &c.Name, &c.Description, &c.ParentID, &c.ArchivedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -35,19 +66,92 @@ func (repo *CategoryRepository) GetAll() ([]model.Category, error) {
 	return categories, nil
 }
 
+// GetTree mengambil semua kategori aktif sebagai pohon, beserta jumlah produk
+// dan nilai persediaan per node maupun per subtree
+func (repo *CategoryRepository) GetTree() ([]model.CategoryNode, error) {
+	query := `
+		SELECT c.id, c.name, COALESCE(c.description, ''), c.parent_id,
+			COUNT(p.id), COALESCE(SUM(GREATEST(p.stock, 0) * p.cost_price), 0)
+		FROM categories c
+		LEFT JOIN products p ON p.category_id = c.id AND p.archived_at IS NULL
+		WHERE c.archived_at IS NULL
+		GROUP BY c.id
+		ORDER BY c.name, c.id`
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	nodes := make([]model.CategoryNode, 0)
+	for rows.Next() {
+		var n model.CategoryNode
+		if err := rows.Scan(&n.ID, &n.Name, &n.Description, &n.ParentID, &n.ProductCount, &n.StockValue); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	childrenOf := make(map[int][]int)
+	roots := make([]int, 0)
+	for i, n := range nodes {
+		if n.ParentID == nil {
+			roots = append(roots, i)
+			continue
+		}
+		childrenOf[*n.ParentID] = append(childrenOf[*n.ParentID], i)
+	}
+
+	var build func(i int) model.CategoryNode
+	build = func(i int) model.CategoryNode {
+		n := nodes[i]
+		n.TotalProductCount = n.ProductCount
+		n.TotalStockValue = n.StockValue
+		n.Children = make([]model.CategoryNode, 0, len(childrenOf[n.ID]))
+		for _, c := range childrenOf[n.ID] {
+			child := build(c)
+			n.TotalProductCount += child.TotalProductCount
+			n.TotalStockValue += child.TotalStockValue
+			n.Children = append(n.Children, child)
+		}
+		return n
+	}
+
+	tree := make([]model.CategoryNode, 0, len(roots))
+	for _, i := range roots {
+		tree = append(tree, build(i))
+	}
+
+	return tree, nil
+}
+
 func (repo *CategoryRepository) Create(category *model.Category) error {
-	query := "INSERT INTO categories (name, description) VALUES ($1, $2) RETURNING id"
-	err := repo.db.QueryRow(query, category.Name, category.Description).Scan(&category.ID)
+	if category.ParentID != nil {
+		if err := checkActiveCategory(repo.db, *category.ParentID, ErrParentCategoryNotFound); err != nil {
+			return err
+		}
+	}
+
+	query := "INSERT INTO categories (name, description, parent_id) VALUES ($1, $2, $3) RETURNING id"
+	err := repo.db.QueryRow(query, category.Name, category.Description, category.ParentID).Scan(&category.ID)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrParentCategoryNotFound
+	}
 	return err
 }
 
 func (repo *CategoryRepository) GetByID(id int) (*model.Category, error) {
-	query := "SELECT id, name, description FROM categories WHERE id = $1"
+	query := "SELECT id, name, description, parent_id, archived_at FROM categories WHERE id = $1"
 
 	var c model.Category
-	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description)
+	err := repo.db.QueryRow(query, id).Scan(&c.ID, &c.Name, &c.Description, &c.ParentID, &c.ArchivedAt)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("category not found")
+		return nil, ErrCategoryNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -56,39 +160,159 @@ func (repo *CategoryRepository) GetByID(id int) (*model.Category, error) {
 	return &c, nil
 }
 
+// Update mengubah nama dan deskripsi kategori. parent_id tidak ikut diubah
+// (harus lewat Move); category.ParentID diisi dengan parent terkini
 func (repo *CategoryRepository) Update(category *model.Category) error {
-	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3"
-	result, err := repo.db.Exec(query, category.Name, category.Description, category.ID)
+	query := "UPDATE categories SET name = $1, description = $2 WHERE id = $3 RETURNING parent_id"
+	err := repo.db.QueryRow(query, category.Name, category.Description, category.ID).Scan(&category.ParentID)
+	if err == sql.ErrNoRows {
+		return ErrCategoryNotFound
+	}
+	return err
+}
+
+// Move memindahkan kategori beserta seluruh sub-kategorinya ke bawah parentID
+// (nil berarti menjadi root). Tabel dikunci dari perubahan lain selama
+// pengecekan siklus, supaya dua pemindahan bersamaan tidak membentuk siklus
+func (repo *CategoryRepository) Move(id int, parentID *int) error {
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	if _, err := tx.Exec("LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return err
+	}
+
+	if err := checkActiveCategory(tx, id, ErrCategoryNotFound); err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		if err := checkActiveCategory(tx, *parentID, ErrParentCategoryNotFound); err != nil {
+			return err
+		}
+
+		var cycle bool
+		err = tx.QueryRow("SELECT $2 IN ("+categorySubtree("$1")+")", id, *parentID).Scan(&cycle)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrCategoryCycle
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE categories SET parent_id = $1 WHERE id = $2", parentID, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete menghapus kategori. targetID nil berarti mode restrict: kategori yang
+// masih punya produk atau sub-kategori ditolak. Jika targetID diisi, produk dan
+// sub-kategorinya dipindah ke target lebih dulu dalam transaksi yang sama
+func (repo *CategoryRepository) Delete(id int, targetID *int) error {
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	if rows == 0 {
-		return errors.New("category not found")
+	// Mengunci baris kategori juga menahan insert produk atau sub-kategori baru
+	// yang mereferensikannya sampai transaksi ini selesai
+	var locked int
+	err = tx.QueryRow("SELECT id FROM categories WHERE id = $1 FOR UPDATE", id).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return ErrCategoryNotFound
+	}
+	if err != nil {
+		return err
 	}
 
-	return nil
+	if targetID == nil {
+		var hasProducts, hasChildren bool
+		err := tx.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM products WHERE category_id = $1),
+				EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)`, id,
+		).Scan(&hasProducts, &hasChildren)
+		if err != nil {
+			return err
+		}
+		if hasProducts {
+			return ErrCategoryHasProducts
+		}
+		if hasChildren {
+			return ErrCategoryHasChildren
+		}
+	} else {
+		var valid bool
+		err := tx.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM categories WHERE id = $2 AND archived_at IS NULL)
+				AND $2 NOT IN (`+categorySubtree("$1")+`)`,
+			id, *targetID,
+		).Scan(&valid)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return ErrInvalidReassignTarget
+		}
+
+		if _, err := tx.Exec("UPDATE products SET category_id = $1 WHERE category_id = $2", *targetID, id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE categories SET parent_id = $1 WHERE parent_id = $2", *targetID, id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM categories WHERE id = $1", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (repo *CategoryRepository) Delete(id int) error {
-	query := "DELETE FROM categories WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+// Archive mengarsipkan kategori beserta seluruh sub-kategorinya. Produknya
+// tetap menunjuk ke kategori yang sama, sehingga laporan per kategori tidak berubah
+func (repo *CategoryRepository) Archive(id int) error {
+	result, err := repo.db.Exec(
+		"UPDATE categories SET archived_at = CURRENT_TIMESTAMP WHERE id IN ("+categorySubtree("$1")+") AND archived_at IS NULL",
+		id,
+	)
 	if err != nil {
 		return err
 	}
+
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
 	if rows == 0 {
-		return errors.New("category not found")
+		return checkActiveCategory(repo.db, id, ErrCategoryNotFound)
 	}
 
-	return err
+	return nil
+}
+
+// checkActiveCategory memastikan kategori ada (jika tidak, notFound yang
+// dikembalikan) dan belum diarsipkan
+func checkActiveCategory(q queryRower, id int, notFound error) error {
+	var archived bool
+	err := q.QueryRow("SELECT archived_at IS NOT NULL FROM categories WHERE id = $1", id).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return notFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if archived {
+		return ErrCategoryArchived
+	}
+	return nil
 }
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/model"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrModifierGroupNotFound dikembalikan saat modifier group tidak ada atau bukan milik produk tersebut
+	ErrModifierGroupNotFound = errors.New("modifier group not found")
+	// ErrModifierNotFound dikembalikan saat mengubah modifier yang bukan anggota group tersebut
+	ErrModifierNotFound = errors.New("modifier not found")
+	// ErrDuplicateModifier dikembalikan saat nama group (per produk) atau nama modifier (per group) sudah dipakai
+	ErrDuplicateModifier = errors.New("modifier group or modifier name already exists")
+)
+
+type ModifierRepository struct {
+	db *sql.DB
+}
+
+func NewModifierRepository(db *sql.DB) *ModifierRepository {
+	return &ModifierRepository{db: db}
+}
+
+func (repo *ModifierRepository) GetByProduct(productID int) ([]model.ModifierGroup, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	return getModifierGroups(repo.db, productID)
+}
+
+// Create menyimpan modifier group baru beserta modifier-modifiernya
+func (repo *ModifierRepository) Create(g *model.ModifierGroup) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO modifier_groups (product_id, name, required, min_select, max_select)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`,
+		g.ProductID, g.Name, g.Required, g.MinSelect, g.MaxSelect,
+	).Scan(&g.ID, &g.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return translateModifierError(err)
+	}
+
+	for i := range g.Modifiers {
+		if err := insertModifier(tx, g.ID, &g.Modifiers[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Update mengganti pengaturan group dan daftar modifiernya. Modifier dengan ID
+// diperbarui, tanpa ID ditambahkan, dan yang tidak disebut lagi dihapus.
+// Riwayat transaksi tetap aman karena modifier sudah di-snapshot saat checkout
+func (repo *ModifierRepository) Update(g *model.ModifierGroup) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		UPDATE modifier_groups SET name = $1, required = $2, min_select = $3, max_select = $4
+		WHERE id = $5 AND product_id = $6
+		RETURNING created_at`,
+		g.Name, g.Required, g.MinSelect, g.MaxSelect, g.ID, g.ProductID,
+	).Scan(&g.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrModifierGroupNotFound
+	}
+	if err != nil {
+		return translateModifierError(err)
+	}
+
+	keep := make([]int, 0, len(g.Modifiers))
+	for _, m := range g.Modifiers {
+		if m.ID != 0 {
+			keep = append(keep, m.ID)
+		}
+	}
+	_, err = tx.Exec("DELETE FROM modifiers WHERE group_id = $1 AND NOT (id = ANY($2))", g.ID, pq.Array(keep))
+	if err != nil {
+		return err
+	}
+
+	for i := range g.Modifiers {
+		m := &g.Modifiers[i]
+		if m.ID == 0 {
+			if err := insertModifier(tx, g.ID, m); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := tx.Exec(
+			"UPDATE modifiers SET name = $1, price_delta = $2 WHERE id = $3 AND group_id = $4",
+			m.Name, m.PriceDelta, m.ID, g.ID,
+		)
+		if err != nil {
+			return translateModifierError(err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return fmt.Errorf("%w: id %d", ErrModifierNotFound, m.ID)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *ModifierRepository) Delete(productID, groupID int) error {
+	result, err := repo.db.Exec("DELETE FROM modifier_groups WHERE id = $1 AND product_id = $2", groupID, productID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrModifierGroupNotFound
+	}
+
+	return nil
+}
+
+func insertModifier(tx *sql.Tx, groupID int, m *model.Modifier) error {
+	err := tx.QueryRow(
+		"INSERT INTO modifiers (group_id, name, price_delta) VALUES ($1, $2, $3) RETURNING id",
+		groupID, m.Name, m.PriceDelta,
+	).Scan(&m.ID)
+	return translateModifierError(err)
+}
+
+// getModifierGroups mengambil semua modifier group sebuah produk beserta modifiernya
+func getModifierGroups(db *sql.DB, productID int) ([]model.ModifierGroup, error) {
+	rows, err := db.Query(`
+		SELECT g.id, g.product_id, g.name, g.required, g.min_select, g.max_select, g.created_at,
+			m.id, m.name, m.price_delta
+		FROM modifier_groups g
+		JOIN modifiers m ON m.group_id = g.id
+		WHERE g.product_id = $1
+		ORDER BY g.id, m.id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := make([]model.ModifierGroup, 0)
+	for rows.Next() {
+		var g model.ModifierGroup
+		var m model.Modifier
+		err := rows.Scan(&g.ID, &g.ProductID, &g.Name, &g.Required, &g.MinSelect, &g.MaxSelect, &g.CreatedAt, &m.ID, &m.Name, &m.PriceDelta)
+		if err != nil {
+			return nil, err
+		}
+
+		if n := len(groups); n > 0 && groups[n-1].ID == g.ID {
+			groups[n-1].Modifiers = append(groups[n-1].Modifiers, m)
+			continue
+		}
+		g.Modifiers = []model.Modifier{m}
+		groups = append(groups, g)
+	}
+
+	return groups, rows.Err()
+}
+
+func translateModifierError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateModifier
+	}
+	return err
+}
@@ -3,9 +3,30 @@ package repositories
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"kasir-api/model"
+
+	"github.com/lib/pq"
 )
 
+// ErrProductNotFound dikembalikan saat produk dengan ID tersebut tidak ada
+var ErrProductNotFound = errors.New("produk tidak ditemukan")
+
+// ErrDuplicateProductCode dikembalikan saat SKU atau barcode sudah dipakai produk atau varian lain
+var ErrDuplicateProductCode = errors.New("sku or barcode is already used by another product")
+
+// ErrTrackExpiryChange dikembalikan saat mengubah track_expiry produk yang masih punya stok
+var ErrTrackExpiryChange = errors.New("track_expiry can only be changed while product stock is zero")
+
+// ErrBaseUnitChange dikembalikan saat mengubah satuan dasar produk yang masih punya stok
+var ErrBaseUnitChange = errors.New("base_unit can only be changed while product stock is zero")
+
+// ErrProductArchived dikembalikan saat mengarsipkan produk yang sudah diarsipkan
+var ErrProductArchived = errors.New("product is already archived")
+
+// ErrProductNotArchived dikembalikan saat me-restore produk yang tidak diarsipkan
+var ErrProductNotArchived = errors.New("product is not archived")
+
 type ProductRepository struct {
 	db *sql.DB
 }
@@ -14,49 +35,167 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (repo *ProductRepository) GetAll(nameFilter string) ([]model.Product, error) {
-	query := "SELECT id, name, price, stock, category_id FROM products"
+// productSortColumns adalah whitelist kolom yang boleh dipakai untuk sorting
+var productSortColumns = map[string]string{
+	"id":    "id",
+	"name":  "name",
+	"price": "price",
+	"stock": "stock",
+}
+
+// GetAll mengambil produk sesuai filter beserta total data (sebelum limit/offset)
+func (repo *ProductRepository) GetAll(filter model.ProductFilter) ([]model.Product, int, error) {
+	where := " WHERE archived_at IS NULL"
+	if filter.Archived {
+		where = " WHERE archived_at IS NOT NULL"
+	}
 	args := []interface{}{}
 
-	if nameFilter != "" {
-		query += " WHERE name ILIKE $1"
-		args = append(args, "%"+nameFilter+"%")
+	if filter.Name != "" {
+		args = append(args, "%"+filter.Name+"%")
+		where += fmt.Sprintf(" AND name ILIKE $%d", len(args))
+	}
+	if filter.CategoryID != 0 {
+		args = append(args, filter.CategoryID)
+		where += fmt.Sprintf(" AND category_id IN (%s)", categorySubtree(fmt.Sprintf("$%d", len(args))))
+	}
+	if filter.MinPrice != nil {
+		args = append(args, *filter.MinPrice)
+		where += fmt.Sprintf(" AND price >= $%d", len(args))
+	}
+	if filter.MaxPrice != nil {
+		args = append(args, *filter.MaxPrice)
+		where += fmt.Sprintf(" AND price <= $%d", len(args))
+	}
+	if filter.InStock {
+		where += " AND stock > 0"
 	}
 
+	var total int
+	err := repo.db.QueryRow("SELECT COUNT(*) FROM products"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortColumn, ok := productSortColumns[filter.Sort]
+	if !ok {
+		sortColumn = "id"
+	}
+	order := "ASC"
+	if filter.Order == "desc" {
+		order = "DESC"
+	}
+
+	// id ditambahkan sebagai tie-breaker supaya urutan antar halaman stabil
+	query := "SELECT id, name, price, cost_price, stock, min_stock, track_expiry, base_unit, COALESCE(category_id, 0), COALESCE(sku, ''), archived_at FROM products" + where
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s", sortColumn, order, order)
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
 	rows, err := repo.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer rows.Close()
 
 	products := make([]model.Product, 0)
 	for rows.Next() {
 		var p model.Product
-		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID)
+		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.CostPrice, &p.Stock, &p.MinStock, &p.TrackExpiry, &p.BaseUnit, &p.CategoryID, &p.SKU, &p.ArchivedAt)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		products = append(products, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 
-	return products, nil
+	if err := repo.attachBarcodes(products); err != nil {
+		return nil, 0, err
+	}
+
+	return products, total, nil
 }
 
-func (repo *ProductRepository) Create(product *model.Product) error {
-	query := "INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4) RETURNING id"
-	err := repo.db.QueryRow(query, product.Name, product.Price, product.Stock, product.CategoryID).Scan(&product.ID)
-	return err
+// Create menyimpan produk baru. Stok awal dicatat sebagai adjustment di ledger
+// stok, bukan ditulis langsung ke kolom stock
+func (repo *ProductRepository) Create(product *model.Product, userID *int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if product.CategoryID != 0 {
+		if err := checkActiveCategory(tx, product.CategoryID, ErrCategoryNotFound); err != nil {
+			return err
+		}
+	}
+
+	taken, err := skuTakenIn(tx, "product_variants", product.SKU)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateProductCode
+	}
+
+	query := "INSERT INTO products (name, price, cost_price, stock, min_stock, track_expiry, base_unit, category_id, sku) VALUES ($1, $2, $3, 0, $4, $5, COALESCE(NULLIF($6, ''), 'pcs'), NULLIF($7, 0), NULLIF($8, '')) RETURNING id, base_unit"
+	err = tx.QueryRow(query, product.Name, product.Price, product.CostPrice, product.MinStock, product.TrackExpiry, product.BaseUnit, product.CategoryID, product.SKU).Scan(&product.ID, &product.BaseUnit)
+	if err != nil {
+		return translateProductError(err)
+	}
+
+	if product.Stock != 0 {
+		err := recordStockMovement(tx, &model.StockMovement{
+			ProductID: product.ID,
+			Type:      model.MovementAdjustment,
+			Quantity:  product.Stock,
+			Reason:    "stok awal",
+			UserID:    userID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := replaceBarcodes(tx, product.ID, product.Barcodes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // GetByID - ambil produk by ID
 func (repo *ProductRepository) GetByID(id int) (*model.Product, error) {
-	query := "SELECT id, name, price, stock, category_id FROM products WHERE id = $1"
+	query := "SELECT id, name, price, cost_price, stock, min_stock, track_expiry, base_unit, COALESCE(category_id, 0), COALESCE(sku, ''), archived_at FROM products WHERE id = $1"
 
 	var p model.Product
-	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID)
+	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.CostPrice, &p.Stock, &p.MinStock, &p.TrackExpiry, &p.BaseUnit, &p.CategoryID, &p.SKU, &p.ArchivedAt)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("produk tidak ditemukan")
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.Barcodes, err = repo.getBarcodes(p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Variants, err = getVariants(repo.db, p.ID)
+	if err != nil {
+		return nil, err
 	}
+
+	p.ModifierGroups, err = getModifierGroups(repo.db, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Units, err = getUnits(repo.db, p.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -64,19 +203,123 @@ func (repo *ProductRepository) GetByID(id int) (*model.Product, error) {
 	return &p, nil
 }
 
+// GetLowStock mengambil produk yang stoknya sudah mencapai atau di bawah batas
+// minimum, diurutkan dari yang paling kritis
+func (repo *ProductRepository) GetLowStock() ([]model.Product, error) {
+	query := `
+		SELECT id, name, price, cost_price, stock, min_stock, track_expiry, base_unit, COALESCE(category_id, 0), COALESCE(sku, ''), archived_at
+		FROM products
+		WHERE min_stock > 0 AND stock <= min_stock AND archived_at IS NULL
+		ORDER BY stock - min_stock, id`
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := make([]model.Product, 0)
+	for rows.Next() {
+		var p model.Product
+		err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.CostPrice, &p.Stock, &p.MinStock, &p.TrackExpiry, &p.BaseUnit, &p.CategoryID, &p.SKU, &p.ArchivedAt)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := repo.attachBarcodes(products); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+const productLotSelect = `
+	SELECT l.id, l.product_id, p.name, l.variant_id, COALESCE(v.name, ''), to_char(l.expiry_date, 'YYYY-MM-DD'),
+		l.received_at, l.quantity, l.remaining, l.unit_cost, COALESCE(l.reference, ''), l.expiry_date - CURRENT_DATE
+	FROM product_lots l
+	JOIN products p ON p.id = l.product_id
+	LEFT JOIN product_variants v ON v.id = l.variant_id`
+
+// GetLots mengambil lot produk yang masih punya sisa stok, urut FEFO
+func (repo *ProductRepository) GetLots(productID int) ([]model.ProductLot, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	return repo.queryProductLots(productLotSelect+`
+		WHERE l.product_id = $1 AND l.remaining > 0
+		ORDER BY l.expiry_date, l.received_at, l.id`, productID)
+}
+
+// GetExpiring mengambil lot yang masih punya sisa stok dan kedaluwarsa dalam
+// days hari ke depan, termasuk yang sudah lewat tanggal kedaluwarsanya
+func (repo *ProductRepository) GetExpiring(days int) ([]model.ProductLot, error) {
+	return repo.queryProductLots(productLotSelect+`
+		WHERE l.remaining > 0 AND l.expiry_date <= CURRENT_DATE + $1::int
+		ORDER BY l.expiry_date, l.product_id, l.id`, days)
+}
+
+func (repo *ProductRepository) queryProductLots(query string, args ...any) ([]model.ProductLot, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make([]model.ProductLot, 0)
+	for rows.Next() {
+		var l model.ProductLot
+		err := rows.Scan(&l.ID, &l.ProductID, &l.ProductName, &l.VariantID, &l.VariantName, &l.ExpiryDate,
+			&l.ReceivedAt, &l.Quantity, &l.Remaining, &l.UnitCost, &l.Reference, &l.DaysLeft)
+		if err != nil {
+			return nil, err
+		}
+		l.Expired = l.DaysLeft < 0
+		lots = append(lots, l)
+	}
+
+	return lots, rows.Err()
+}
+
+// GetByBarcode - ambil produk berdasarkan salah satu barcode-nya
+func (repo *ProductRepository) GetByBarcode(code string) (*model.Product, error) {
+	var productID int
+	err := repo.db.QueryRow(`
+		SELECT b.product_id FROM product_barcodes b
+		JOIN products p ON p.id = b.product_id
+		WHERE b.code = $1 AND p.archived_at IS NULL`, code).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("produk dengan barcode tersebut tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.GetByID(productID)
+}
+
 // GetByIDWithCategory - ambil produk by ID dengan JOIN ke categories
 func (repo *ProductRepository) GetByIDWithCategory(id int) (*model.ProductWithCategory, error) {
 	query := `
-		SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name 
-		FROM products p 
-		LEFT JOIN categories c ON p.category_id = c.id 
+		SELECT p.id, p.name, p.price, p.cost_price, p.stock, p.min_stock, p.track_expiry, p.base_unit, COALESCE(p.category_id, 0), COALESCE(p.sku, ''), p.archived_at, c.name
+		FROM products p
+		LEFT JOIN categories c ON p.category_id = c.id
 		WHERE p.id = $1`
 
 	var p model.ProductWithCategory
 	var categoryName sql.NullString
-	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.CategoryID, &categoryName)
+	err := repo.db.QueryRow(query, id).Scan(&p.ID, &p.Name, &p.Price, &p.CostPrice, &p.Stock, &p.MinStock, &p.TrackExpiry, &p.BaseUnit, &p.CategoryID, &p.SKU, &p.ArchivedAt, &categoryName)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("produk tidak ditemukan")
+		return nil, ErrProductNotFound
 	}
 	if err != nil {
 		return nil, err
@@ -86,42 +329,236 @@ func (repo *ProductRepository) GetByIDWithCategory(id int) (*model.ProductWithCa
 		p.CategoryName = categoryName.String
 	}
 
+	p.Barcodes, err = repo.getBarcodes(p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Variants, err = getVariants(repo.db, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.ModifierGroups, err = getModifierGroups(repo.db, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Units, err = getUnits(repo.db, p.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	return &p, nil
 }
 
-func (repo *ProductRepository) Update(product *model.Product) error {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5"
-	result, err := repo.db.Exec(query, product.Name, product.Price, product.Stock, product.CategoryID, product.ID)
+// Update mengubah data produk. Stok dan HPP tidak ikut diubah (harus lewat
+// stock movement); product.Stock dan product.CostPrice diisi dengan nilai
+// terkini. minStock dan trackExpiry nil berarti tidak diubah. Barcodes nil berarti
+// barcode tidak diubah, sedangkan slice kosong berarti semua barcode dihapus.
+// track_expiry hanya boleh diubah saat stok 0, supaya stok selalu sama dengan
+// jumlah sisa lot-nya. Begitu juga base_unit, karena stok dan faktor konversi
+// satuan alternatif dinyatakan dalam satuan dasar; base_unit kosong berarti tidak diubah
+func (repo *ProductRepository) Update(product *model.Product, minStock *int, trackExpiry *bool) error {
+	tx, err := repo.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	var stock, categoryID int
+	var baseUnit string
+	err = tx.QueryRow(
+		"SELECT stock, cost_price, min_stock, track_expiry, base_unit, COALESCE(category_id, 0) FROM products WHERE id = $1 FOR UPDATE",
+		product.ID,
+	).Scan(&stock, &product.CostPrice, &product.MinStock, &product.TrackExpiry, &baseUnit, &categoryID)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
 	if err != nil {
 		return err
 	}
+	if minStock != nil {
+		product.MinStock = *minStock
+	}
+	if trackExpiry != nil && *trackExpiry != product.TrackExpiry {
+		if stock != 0 {
+			return ErrTrackExpiryChange
+		}
+		product.TrackExpiry = *trackExpiry
+	}
+	if product.BaseUnit == "" {
+		product.BaseUnit = baseUnit
+	}
+	if product.BaseUnit != baseUnit && stock != 0 {
+		return ErrBaseUnitChange
+	}
+	// Produk yang sudah ada di kategori arsip tetap boleh diedit, tapi tidak
+	// boleh dipindah ke kategori arsip lain
+	if product.CategoryID != 0 && product.CategoryID != categoryID {
+		if err := checkActiveCategory(tx, product.CategoryID, ErrCategoryNotFound); err != nil {
+			return err
+		}
+	}
 
-	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+	taken, err := skuTakenIn(tx, "product_variants", product.SKU)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateProductCode
 	}
 
-	return nil
+	query := "UPDATE products SET name = $1, price = $2, min_stock = $3, track_expiry = $4, base_unit = $5, category_id = NULLIF($6, 0), sku = NULLIF($7, '') WHERE id = $8 RETURNING stock"
+	err = tx.QueryRow(query, product.Name, product.Price, product.MinStock, product.TrackExpiry, product.BaseUnit, product.CategoryID, product.SKU, product.ID).Scan(&product.Stock)
+	if err != nil {
+		return translateProductError(err)
+	}
+
+	if product.Barcodes != nil {
+		if err := replaceBarcodes(tx, product.ID, product.Barcodes); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
+// Delete mengarsipkan produk (soft delete). Produk yang diarsipkan hilang dari
+// katalog dan checkout, tapi tetap tercatat di riwayat transaksi, stok, dan laporan
 func (repo *ProductRepository) Delete(id int) error {
-	query := "DELETE FROM products WHERE id = $1"
-	result, err := repo.db.Exec(query, id)
+	result, err := repo.db.Exec("UPDATE products SET archived_at = CURRENT_TIMESTAMP WHERE id = $1 AND archived_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+
+	return repo.checkArchiveResult(result, id, ErrProductArchived)
+}
+
+// Restore mengaktifkan kembali produk yang sudah diarsipkan
+func (repo *ProductRepository) Restore(id int) error {
+	result, err := repo.db.Exec("UPDATE products SET archived_at = NULL WHERE id = $1 AND archived_at IS NOT NULL", id)
 	if err != nil {
 		return err
 	}
+
+	return repo.checkArchiveResult(result, id, ErrProductNotArchived)
+}
+
+// checkArchiveResult membedakan produk yang tidak ada dengan produk yang sudah
+// berada di status tujuan saat update arsip tidak mengenai baris apa pun
+func (repo *ProductRepository) checkArchiveResult(result sql.Result, id int, stateErr error) error {
 	rows, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if rows > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrProductNotFound
+	}
+	return stateErr
+}
+
+func (repo *ProductRepository) getBarcodes(productID int) ([]string, error) {
+	rows, err := repo.db.Query("SELECT code FROM product_barcodes WHERE product_id = $1 ORDER BY id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	barcodes := make([]string, 0)
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		barcodes = append(barcodes, code)
+	}
+
+	return barcodes, rows.Err()
+}
+
+// attachBarcodes mengisi barcode untuk banyak produk sekaligus dengan satu query
+func (repo *ProductRepository) attachBarcodes(products []model.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(products))
+	index := make(map[int]int, len(products))
+	for i := range products {
+		ids[i] = products[i].ID
+		index[products[i].ID] = i
+		products[i].Barcodes = make([]string, 0)
+	}
+
+	rows, err := repo.db.Query("SELECT product_id, code FROM product_barcodes WHERE product_id = ANY($1) ORDER BY id", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID int
+		var code string
+		if err := rows.Scan(&productID, &code); err != nil {
+			return err
+		}
+		i := index[productID]
+		products[i].Barcodes = append(products[i].Barcodes, code)
+	}
+
+	return rows.Err()
+}
 
-	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+func replaceBarcodes(tx *sql.Tx, productID int, barcodes []string) error {
+	_, err := tx.Exec("DELETE FROM product_barcodes WHERE product_id = $1", productID)
+	if err != nil {
+		return err
 	}
 
+	for _, code := range barcodes {
+		_, err := tx.Exec("INSERT INTO product_barcodes (product_id, code) VALUES ($1, $2)", productID, code)
+		if err != nil {
+			return translateProductError(err)
+		}
+	}
+
+	return nil
+}
+
+// skuTakenIn mengunci SKU sampai transaksi selesai, lalu mengecek apakah SKU
+// tersebut sudah dipakai di table lain (products atau product_variants).
+// Keunikan di dalam satu tabel dijaga unique index masing-masing, sedangkan
+// lock ini mencegah produk dan varian memakai SKU yang sama secara bersamaan,
+// sehingga pencarian per SKU tidak pernah ambigu
+func skuTakenIn(tx *sql.Tx, table, sku string) (bool, error) {
+	if sku == "" {
+		return false, nil
+	}
+
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('sku:' || $1))", sku); err != nil {
+		return false, err
+	}
+
+	var taken bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM "+table+" WHERE sku = $1)", sku).Scan(&taken)
+	return taken, err
+}
+
+// translateProductError mengubah unique violation pada sku/barcode menjadi ErrDuplicateProductCode
+func translateProductError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateProductCode
+	}
 	return err
 }
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/model"
+	"math"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrUnitNotFound dikembalikan saat satuan tidak ada atau bukan milik produk tersebut
+	ErrUnitNotFound = errors.New("unit not found")
+	// ErrDuplicateUnit dikembalikan saat nama satuan sudah dipakai produk yang sama atau sama dengan satuan dasarnya
+	ErrDuplicateUnit = errors.New("unit name already exists for this product")
+	// ErrInvalidQuantity dikembalikan saat quantity tidak menjadi bilangan bulat bukan nol dalam satuan dasar
+	ErrInvalidQuantity = errors.New("quantity must convert to a non-zero whole number of the product's base unit")
+)
+
+type ProductUnitRepository struct {
+	db *sql.DB
+}
+
+func NewProductUnitRepository(db *sql.DB) *ProductUnitRepository {
+	return &ProductUnitRepository{db: db}
+}
+
+func (repo *ProductUnitRepository) GetByProduct(productID int) ([]model.ProductUnit, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	return getUnits(repo.db, productID)
+}
+
+func (repo *ProductUnitRepository) Create(u *model.ProductUnit) error {
+	if err := checkUnitName(repo.db, u.ProductID, u.Name); err != nil {
+		return err
+	}
+
+	err := repo.db.QueryRow(
+		"INSERT INTO product_units (product_id, name, factor, price) VALUES ($1, $2, $3, $4) RETURNING id",
+		u.ProductID, u.Name, u.Factor, u.Price,
+	).Scan(&u.ID)
+	return translateUnitError(err)
+}
+
+func (repo *ProductUnitRepository) Update(u *model.ProductUnit) error {
+	if err := checkUnitName(repo.db, u.ProductID, u.Name); err != nil {
+		return err
+	}
+
+	result, err := repo.db.Exec(
+		"UPDATE product_units SET name = $1, factor = $2, price = $3 WHERE id = $4 AND product_id = $5",
+		u.Name, u.Factor, u.Price, u.ID, u.ProductID,
+	)
+	if err != nil {
+		return translateUnitError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUnitNotFound
+	}
+
+	return nil
+}
+
+func (repo *ProductUnitRepository) Delete(productID, unitID int) error {
+	result, err := repo.db.Exec("DELETE FROM product_units WHERE id = $1 AND product_id = $2", unitID, productID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUnitNotFound
+	}
+
+	return nil
+}
+
+// queryRower dipenuhi oleh *sql.DB dan *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// checkUnitName memastikan produk ada dan nama satuan tidak sama dengan satuan dasarnya
+func checkUnitName(q queryRower, productID int, name string) error {
+	var baseUnit string
+	err := q.QueryRow("SELECT base_unit FROM products WHERE id = $1", productID).Scan(&baseUnit)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if name == baseUnit {
+		return ErrDuplicateUnit
+	}
+	return nil
+}
+
+// resolveUnit mengembalikan nama dan faktor konversi satuan sebuah produk.
+// Satuan kosong atau sama dengan satuan dasar berarti faktor 1
+func resolveUnit(q queryRower, productID int, unit string) (string, int, error) {
+	var baseUnit string
+	err := q.QueryRow("SELECT base_unit FROM products WHERE id = $1", productID).Scan(&baseUnit)
+	if err == sql.ErrNoRows {
+		return "", 0, fmt.Errorf("%w: id %d", ErrProductNotFound, productID)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	if unit == "" || unit == baseUnit {
+		return baseUnit, 1, nil
+	}
+
+	var factor int
+	err = q.QueryRow("SELECT factor FROM product_units WHERE product_id = $1 AND name = $2", productID, unit).Scan(&factor)
+	if err == sql.ErrNoRows {
+		return "", 0, fmt.Errorf("%w: %s (product id %d)", ErrUnitNotFound, unit, productID)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	return unit, factor, nil
+}
+
+// toBaseQuantity mengonversi quantity dalam satuan tertentu ke satuan dasar.
+// Hasilnya harus bilangan bulat (toleransi pembulatan float kecil), karena stok
+// disimpan sebagai bilangan bulat satuan dasar. Tanda quantity dipertahankan
+func toBaseQuantity(quantity float64, factor int) (int, error) {
+	base := quantity * float64(factor)
+	rounded := math.Round(base)
+	if rounded == 0 || math.Abs(base-rounded) > 1e-6 {
+		return 0, fmt.Errorf("%w (got %v x %d)", ErrInvalidQuantity, quantity, factor)
+	}
+	return int(rounded), nil
+}
+
+// getUnits mengambil semua satuan alternatif sebuah produk
+func getUnits(db *sql.DB, productID int) ([]model.ProductUnit, error) {
+	rows, err := db.Query("SELECT id, product_id, name, factor, price FROM product_units WHERE product_id = $1 ORDER BY factor, id", productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := make([]model.ProductUnit, 0)
+	for rows.Next() {
+		var u model.ProductUnit
+		if err := rows.Scan(&u.ID, &u.ProductID, &u.Name, &u.Factor, &u.Price); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+
+	return units, rows.Err()
+}
+
+func translateUnitError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateUnit
+	}
+	return err
+}
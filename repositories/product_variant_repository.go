@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"kasir-api/model"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrDuplicateVariant dikembalikan saat nama varian (per produk) sudah dipakai, atau SKU-nya sudah dipakai varian maupun produk lain
+	ErrDuplicateVariant = errors.New("variant name or sku already exists")
+	// ErrProductHasStock dikembalikan saat menambah varian pertama ke produk yang masih punya stok
+	ErrProductHasStock = errors.New("product stock must be zero before adding its first variant")
+	// ErrVariantInUse dikembalikan saat menghapus varian yang sudah punya riwayat stok atau penjualan
+	ErrVariantInUse = errors.New("variant has stock or sales history and cannot be deleted")
+)
+
+type ProductVariantRepository struct {
+	db *sql.DB
+}
+
+func NewProductVariantRepository(db *sql.DB) *ProductVariantRepository {
+	return &ProductVariantRepository{db: db}
+}
+
+func (repo *ProductVariantRepository) GetByProduct(productID int) ([]model.ProductVariant, error) {
+	var exists bool
+	err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", productID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+
+	return getVariants(repo.db, productID)
+}
+
+// Create menambahkan varian baru. Stok awal varian dicatat sebagai adjustment
+// di ledger, yang sekaligus menambah stok produk induk
+func (repo *ProductVariantRepository) Create(v *model.ProductVariant, userID *int) error {
+	options, err := json.Marshal(v.Options)
+	if err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parentStock int
+	err = tx.QueryRow("SELECT stock FROM products WHERE id = $1 FOR UPDATE", v.ProductID).Scan(&parentStock)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Stok induk harus selalu sama dengan jumlah stok varian, jadi stok yang
+	// belum terbagi ke varian tidak boleh ada saat varian pertama dibuat
+	hasVariants, err := productHasVariants(tx, v.ProductID)
+	if err != nil {
+		return err
+	}
+	if !hasVariants && parentStock != 0 {
+		return ErrProductHasStock
+	}
+	isComposite, err := productHasRecipe(tx, v.ProductID)
+	if err != nil {
+		return err
+	}
+	if isComposite {
+		return ErrCompositeConflict
+	}
+
+	taken, err := skuTakenIn(tx, "products", v.SKU)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateVariant
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO product_variants (product_id, name, options, sku, price)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		RETURNING id, created_at`,
+		v.ProductID, v.Name, options, v.SKU, v.Price,
+	).Scan(&v.ID, &v.CreatedAt)
+	if err != nil {
+		return translateVariantError(err)
+	}
+
+	if v.Stock != 0 {
+		err := recordStockMovement(tx, &model.StockMovement{
+			ProductID: v.ProductID,
+			VariantID: &v.ID,
+			Type:      model.MovementAdjustment,
+			Quantity:  v.Stock,
+			Reason:    "stok awal",
+			UserID:    userID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Update mengubah nama, opsi, SKU, dan harga varian. Stok tidak ikut diubah
+// (harus lewat stock movement); v.Stock diisi dengan stok terkini
+func (repo *ProductVariantRepository) Update(v *model.ProductVariant) error {
+	options, err := json.Marshal(v.Options)
+	if err != nil {
+		return err
+	}
+
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	taken, err := skuTakenIn(tx, "products", v.SKU)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateVariant
+	}
+
+	err = tx.QueryRow(`
+		UPDATE product_variants SET name = $1, options = $2, sku = NULLIF($3, ''), price = $4
+		WHERE id = $5 AND product_id = $6
+		RETURNING stock, created_at`,
+		v.Name, options, v.SKU, v.Price, v.ID, v.ProductID,
+	).Scan(&v.Stock, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrVariantNotFound
+	}
+	if err != nil {
+		return translateVariantError(err)
+	}
+
+	return tx.Commit()
+}
+
+// Delete menghapus varian yang belum pernah punya stok maupun penjualan
+func (repo *ProductVariantRepository) Delete(productID, variantID int) error {
+	result, err := repo.db.Exec("DELETE FROM product_variants WHERE id = $1 AND product_id = $2", variantID, productID)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrVariantInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrVariantNotFound
+	}
+
+	return nil
+}
+
+// getVariants mengambil semua varian sebuah produk
+func getVariants(db *sql.DB, productID int) ([]model.ProductVariant, error) {
+	rows, err := db.Query(`
+		SELECT id, product_id, name, options, COALESCE(sku, ''), price, stock, created_at
+		FROM product_variants
+		WHERE product_id = $1
+		ORDER BY id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variants := make([]model.ProductVariant, 0)
+	for rows.Next() {
+		var v model.ProductVariant
+		var options []byte
+		if err := rows.Scan(&v.ID, &v.ProductID, &v.Name, &options, &v.SKU, &v.Price, &v.Stock, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(options, &v.Options); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, rows.Err()
+}
+
+func translateVariantError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDuplicateVariant
+	}
+	return err
+}
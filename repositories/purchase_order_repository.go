@@ -0,0 +1,309 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/model"
+	"math"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrPurchaseOrderNotFound dikembalikan saat purchase order dengan ID tersebut tidak ada
+	ErrPurchaseOrderNotFound = errors.New("purchase order not found")
+	// ErrPurchaseOrderStatus dikembalikan saat aksi tidak diizinkan untuk status PO saat ini
+	ErrPurchaseOrderStatus = errors.New("action not allowed for current purchase order status")
+	// ErrItemNotInPurchaseOrder dikembalikan saat menerima produk (atau varian) yang tidak ada di PO
+	ErrItemNotInPurchaseOrder = errors.New("product is not part of this purchase order")
+	// ErrOverReceive dikembalikan saat jumlah diterima melebihi jumlah yang dipesan
+	ErrOverReceive = errors.New("received quantity exceeds ordered quantity")
+	// ErrProductNotOrderable dikembalikan saat membuat PO untuk produk yang sudah diarsipkan
+	ErrProductNotOrderable = errors.New("product is archived and cannot be ordered")
+)
+
+type PurchaseOrderRepository struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepository(db *sql.DB) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{db: db}
+}
+
+const purchaseOrderSelect = `
+	SELECT po.id, po.supplier_id, s.name, po.status, COALESCE(po.note, ''),
+		COALESCE((SELECT SUM(quantity * unit_cost) FROM purchase_order_items WHERE purchase_order_id = po.id), 0),
+		po.created_by, po.created_at, po.updated_at
+	FROM purchase_orders po
+	JOIN suppliers s ON s.id = po.supplier_id`
+
+func scanPurchaseOrder(row interface{ Scan(...any) error }, po *model.PurchaseOrder) error {
+	return row.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.Status, &po.Note, &po.TotalCost, &po.CreatedBy, &po.CreatedAt, &po.UpdatedAt)
+}
+
+func (repo *PurchaseOrderRepository) GetAll(filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where += fmt.Sprintf(" AND po.status = $%d", len(args))
+	}
+	if filter.SupplierID != 0 {
+		args = append(args, filter.SupplierID)
+		where += fmt.Sprintf(" AND po.supplier_id = $%d", len(args))
+	}
+
+	rows, err := repo.db.Query(purchaseOrderSelect+where+" ORDER BY po.id DESC", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := make([]model.PurchaseOrder, 0)
+	for rows.Next() {
+		var po model.PurchaseOrder
+		if err := scanPurchaseOrder(rows, &po); err != nil {
+			return nil, err
+		}
+		orders = append(orders, po)
+	}
+
+	return orders, rows.Err()
+}
+
+func (repo *PurchaseOrderRepository) GetByID(id int) (*model.PurchaseOrder, error) {
+	var po model.PurchaseOrder
+	err := scanPurchaseOrder(repo.db.QueryRow(purchaseOrderSelect+" WHERE po.id = $1", id), &po)
+	if err == sql.ErrNoRows {
+		return nil, ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT i.id, i.product_id, p.name, i.variant_id, COALESCE(v.name, ''), COALESCE(i.unit_name, p.base_unit),
+			i.unit_factor, i.quantity, i.unit_cost, i.received_qty
+		FROM purchase_order_items i
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN product_variants v ON v.id = i.variant_id
+		WHERE i.purchase_order_id = $1
+		ORDER BY i.id`
+	rows, err := repo.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	po.Items = make([]model.PurchaseOrderItem, 0)
+	for rows.Next() {
+		var item model.PurchaseOrderItem
+		err := rows.Scan(&item.ID, &item.ProductID, &item.ProductName, &item.VariantID, &item.VariantName, &item.Unit,
+			&item.UnitFactor, &item.Quantity, &item.UnitCost, &item.ReceivedQty)
+		if err != nil {
+			return nil, err
+		}
+		po.Items = append(po.Items, item)
+	}
+
+	return &po, rows.Err()
+}
+
+// Create menyimpan purchase order baru berstatus draft beserta item-itemnya
+func (repo *PurchaseOrderRepository) Create(po *model.PurchaseOrder, items []model.PurchaseOrderItemRequest) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM suppliers WHERE id = $1)", po.SupplierID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrSupplierNotFound
+	}
+
+	err = tx.QueryRow(
+		"INSERT INTO purchase_orders (supplier_id, note, created_by) VALUES ($1, NULLIF($2, ''), $3) RETURNING id, status, created_at, updated_at",
+		po.SupplierID, po.Note, po.CreatedBy,
+	).Scan(&po.ID, &po.Status, &po.CreatedAt, &po.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var archived bool
+		err := tx.QueryRow("SELECT archived_at IS NOT NULL FROM products WHERE id = $1", item.ProductID).Scan(&archived)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: id %d", ErrProductNotFound, item.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+		if archived {
+			return fmt.Errorf("%w (product id %d)", ErrProductNotOrderable, item.ProductID)
+		}
+
+		// Stok produk bervarian dicatat per varian, jadi item PO-nya juga
+		if err := checkVariantLine(tx, item.ProductID, item.VariantID); err != nil {
+			return err
+		}
+		// Stok produk komposit selalu berasal dari bahannya
+		isComposite, err := productHasRecipe(tx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		if isComposite {
+			return fmt.Errorf("%w (product id %d)", ErrCompositeConflict, item.ProductID)
+		}
+
+		// Faktor konversi di-snapshot, supaya perubahan satuan produk setelah PO
+		// dibuat tidak mengubah jumlah stok yang masuk saat barang diterima
+		unit, factor, err := resolveUnit(tx, item.ProductID, item.Unit)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO purchase_order_items (purchase_order_id, product_id, variant_id, unit_name, unit_factor, quantity, unit_cost) VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6, $7)",
+			po.ID, item.ProductID, item.VariantID, unit, factor, item.Quantity, item.UnitCost,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStatus memindahkan status PO hanya jika status saat ini termasuk dalam from
+func (repo *PurchaseOrderRepository) UpdateStatus(id int, from []string, to string) error {
+	result, err := repo.db.Exec(
+		"UPDATE purchase_orders SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2 AND status = ANY($3)",
+		to, id, pq.Array(from),
+	)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		var exists bool
+		err := repo.db.QueryRow("SELECT EXISTS (SELECT 1 FROM purchase_orders WHERE id = $1)", id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrPurchaseOrderNotFound
+		}
+		return ErrPurchaseOrderStatus
+	}
+
+	return nil
+}
+
+// Receive mencatat penerimaan barang: menambah received_qty item PO, menambah
+// stok produk lewat ledger (restock), lalu memperbarui status PO menjadi
+// partially_received atau received. Semuanya dalam satu transaksi database.
+// Quantity penerimaan mengikuti satuan beli item PO dan dikonversi ke satuan dasar
+// untuk ledger, begitu juga harga belinya
+func (repo *PurchaseOrderRepository) Receive(id int, req model.ReceivePurchaseOrderRequest, userID *int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrPurchaseOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != model.PurchaseOrderSent && status != model.PurchaseOrderPartiallyReceived {
+		return ErrPurchaseOrderStatus
+	}
+
+	reason := "goods receipt"
+	if req.Reference != "" {
+		reason += ": " + req.Reference
+	}
+
+	for _, item := range req.Items {
+		var itemID, ordered, received, unitCost, factor int
+		var variantID *int
+		err := tx.QueryRow(`
+			SELECT id, variant_id, quantity, received_qty, unit_cost, unit_factor
+			FROM purchase_order_items
+			WHERE purchase_order_id = $1 AND product_id = $2 AND COALESCE(variant_id, 0) = $3
+			FOR UPDATE`,
+			id, item.ProductID, item.VariantID,
+		).Scan(&itemID, &variantID, &ordered, &received, &unitCost, &factor)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: product id %d, variant id %d", ErrItemNotInPurchaseOrder, item.ProductID, item.VariantID)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Resep bisa saja ditambahkan setelah PO dibuat
+		isComposite, err := productHasRecipe(tx, item.ProductID)
+		if err != nil {
+			return err
+		}
+		if isComposite {
+			return fmt.Errorf("%w (product id %d)", ErrCompositeConflict, item.ProductID)
+		}
+
+		if received+item.Quantity > ordered {
+			return fmt.Errorf("%w: product id %d (ordered %d, received %d)", ErrOverReceive, item.ProductID, ordered, received)
+		}
+
+		_, err = tx.Exec("UPDATE purchase_order_items SET received_qty = received_qty + $1 WHERE id = $2", item.Quantity, itemID)
+		if err != nil {
+			return err
+		}
+
+		baseUnitCost := int(math.Round(float64(unitCost) / float64(factor)))
+		err = recordStockMovement(tx, &model.StockMovement{
+			ProductID:  item.ProductID,
+			VariantID:  variantID,
+			Type:       model.MovementRestock,
+			Quantity:   item.Quantity * factor,
+			UnitCost:   &baseUnitCost,
+			ExpiryDate: item.ExpiryDate,
+			Reason:     reason,
+			Reference:  fmt.Sprintf("purchase_order:%d", id),
+			UserID:     userID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE purchase_orders
+		SET status = CASE
+				WHEN NOT EXISTS (SELECT 1 FROM purchase_order_items WHERE purchase_order_id = $1 AND received_qty < quantity)
+				THEN $2 ELSE $3 END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`,
+		id, model.PurchaseOrderReceived, model.PurchaseOrderPartiallyReceived,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
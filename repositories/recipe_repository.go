@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/model"
+)
+
+var (
+	// ErrInvalidIngredient dikembalikan saat bahan resep punya varian atau resep sendiri
+	ErrInvalidIngredient = errors.New("ingredient must be a product without variants and without its own recipe")
+	// ErrCompositeConflict dikembalikan saat produk komposit akan diberi varian atau stok sendiri,
+	// atau dipakai sebagai bahan resep lain
+	ErrCompositeConflict = errors.New("composite product cannot have variants or stock of its own, nor be used as an ingredient")
+)
+
+type RecipeRepository struct {
+	db *sql.DB
+}
+
+func NewRecipeRepository(db *sql.DB) *RecipeRepository {
+	return &RecipeRepository{db: db}
+}
+
+// GetByProduct mengambil resep sebuah produk beserta HPP per unit dan jumlah
+// unit yang masih bisa dibuat. Produk tanpa resep mengembalikan daftar bahan kosong
+func (repo *RecipeRepository) GetByProduct(productID int) (*model.Recipe, error) {
+	recipe := model.Recipe{ProductID: productID}
+	err := repo.db.QueryRow("SELECT name FROM products WHERE id = $1", productID).Scan(&recipe.ProductName)
+	if err == sql.ErrNoRows {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT r.ingredient_id, p.name, r.quantity, p.cost_price, p.stock
+		FROM product_recipes r
+		JOIN products p ON p.id = r.ingredient_id
+		WHERE r.product_id = $1
+		ORDER BY p.name, r.ingredient_id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipe.Ingredients = make([]model.RecipeIngredient, 0)
+	for rows.Next() {
+		var ing model.RecipeIngredient
+		if err := rows.Scan(&ing.IngredientID, &ing.IngredientName, &ing.Quantity, &ing.CostPrice, &ing.Stock); err != nil {
+			return nil, err
+		}
+
+		recipe.UnitCost += ing.CostPrice * ing.Quantity
+		available := max(ing.Stock, 0) / ing.Quantity
+		if len(recipe.Ingredients) == 0 || available < recipe.Available {
+			recipe.Available = available
+		}
+		recipe.Ingredients = append(recipe.Ingredients, ing)
+	}
+
+	return &recipe, rows.Err()
+}
+
+// Replace mengganti seluruh resep produk dalam satu transaksi. Produk komposit
+// tidak boleh punya varian atau stok sendiri, dan resep hanya satu tingkat:
+// bahan tidak boleh berupa produk komposit
+func (repo *RecipeRepository) Replace(productID int, ingredients []model.RecipeIngredient) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var stock int
+	err = tx.QueryRow("SELECT stock FROM products WHERE id = $1 FOR UPDATE", productID).Scan(&stock)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM product_recipes WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+
+	if len(ingredients) == 0 {
+		return tx.Commit()
+	}
+
+	hasVariants, err := productHasVariants(tx, productID)
+	if err != nil {
+		return err
+	}
+	var isIngredient bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_recipes WHERE ingredient_id = $1)", productID).Scan(&isIngredient)
+	if err != nil {
+		return err
+	}
+	if hasVariants || stock != 0 || isIngredient {
+		return ErrCompositeConflict
+	}
+
+	for _, ing := range ingredients {
+		var exists bool
+		err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", ing.IngredientID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("%w: id %d", ErrProductNotFound, ing.IngredientID)
+		}
+
+		hasVariants, err := productHasVariants(tx, ing.IngredientID)
+		if err != nil {
+			return err
+		}
+		isComposite, err := productHasRecipe(tx, ing.IngredientID)
+		if err != nil {
+			return err
+		}
+		if hasVariants || isComposite || ing.IngredientID == productID {
+			return fmt.Errorf("%w (product id %d)", ErrInvalidIngredient, ing.IngredientID)
+		}
+
+		_, err = tx.Exec(
+			"INSERT INTO product_recipes (product_id, ingredient_id, quantity) VALUES ($1, $2, $3)",
+			productID, ing.IngredientID, ing.Quantity,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// productHasRecipe mengecek apakah produk adalah produk komposit
+func productHasRecipe(tx *sql.Tx, productID int) (bool, error) {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_recipes WHERE product_id = $1)", productID).Scan(&exists)
+	return exists, err
+}
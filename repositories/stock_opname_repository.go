@@ -0,0 +1,289 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/model"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrOpnameNotFound dikembalikan saat sesi stock opname tidak ada
+	ErrOpnameNotFound = errors.New("stock opname not found")
+	// ErrOpnameAlreadyOpen dikembalikan saat memulai sesi baru padahal masih ada sesi yang open
+	ErrOpnameAlreadyOpen = errors.New("another stock opname is still open")
+	// ErrOpnameClosed dikembalikan saat mengubah sesi yang sudah difinalisasi atau dibatalkan
+	ErrOpnameClosed = errors.New("stock opname is already closed")
+)
+
+type StockOpnameRepository struct {
+	db *sql.DB
+}
+
+func NewStockOpnameRepository(db *sql.DB) *StockOpnameRepository {
+	return &StockOpnameRepository{db: db}
+}
+
+const stockOpnameColumns = "id, status, COALESCE(note, ''), started_by, started_at, finalized_by, finalized_at"
+
+func scanStockOpname(row interface{ Scan(...any) error }, o *model.StockOpname) error {
+	return row.Scan(&o.ID, &o.Status, &o.Note, &o.StartedBy, &o.StartedAt, &o.FinalizedBy, &o.FinalizedAt)
+}
+
+func (repo *StockOpnameRepository) Create(o *model.StockOpname) error {
+	query := `
+		INSERT INTO stock_opnames (note, started_by)
+		VALUES (NULLIF($1, ''), $2)
+		RETURNING id, status, started_at`
+	err := repo.db.QueryRow(query, o.Note, o.StartedBy).Scan(&o.ID, &o.Status, &o.StartedAt)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrOpnameAlreadyOpen
+	}
+	return err
+}
+
+func (repo *StockOpnameRepository) GetAll() ([]model.StockOpname, error) {
+	rows, err := repo.db.Query("SELECT " + stockOpnameColumns + " FROM stock_opnames ORDER BY id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	opnames := make([]model.StockOpname, 0)
+	for rows.Next() {
+		var o model.StockOpname
+		if err := scanStockOpname(rows, &o); err != nil {
+			return nil, err
+		}
+		opnames = append(opnames, o)
+	}
+
+	return opnames, rows.Err()
+}
+
+func (repo *StockOpnameRepository) GetByID(id int) (*model.StockOpname, error) {
+	var o model.StockOpname
+	err := scanStockOpname(repo.db.QueryRow("SELECT "+stockOpnameColumns+" FROM stock_opnames WHERE id = $1", id), &o)
+	if err == sql.ErrNoRows {
+		return nil, ErrOpnameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// GetItems mengambil hasil hitungan sebuah sesi beserta selisihnya terhadap stok sistem
+func (repo *StockOpnameRepository) GetItems(opnameID int) ([]model.StockOpnameItem, error) {
+	query := `
+		SELECT i.product_id, p.name, i.variant_id, COALESCE(v.name, ''), i.system_stock, i.counted_qty,
+			COALESCE(to_char(i.expiry_date, 'YYYY-MM-DD'), ''), i.unit_price, i.counted_by, i.counted_at
+		FROM stock_opname_items i
+		JOIN products p ON p.id = i.product_id
+		LEFT JOIN product_variants v ON v.id = i.variant_id
+		WHERE i.opname_id = $1
+		ORDER BY p.name, i.product_id, v.name`
+	rows, err := repo.db.Query(query, opnameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.StockOpnameItem, 0)
+	for rows.Next() {
+		var item model.StockOpnameItem
+		err := rows.Scan(&item.ProductID, &item.ProductName, &item.VariantID, &item.VariantName, &item.SystemStock, &item.CountedQty,
+			&item.ExpiryDate, &item.UnitPrice, &item.CountedBy, &item.CountedAt)
+		if err != nil {
+			return nil, err
+		}
+		item.Variance = item.CountedQty - item.SystemStock
+		item.VarianceValue = item.Variance * item.UnitPrice
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// SubmitCounts menyimpan satu batch hitungan. Stok sistem dan harga produk
+// di-snapshot saat itu juga, sehingga penjualan yang terjadi setelah produk
+// dihitung tidak ikut dianggap selisih. Produk bervarian dihitung per varian,
+// memakai stok dan harga variannya. Surplus produk track_expiry wajib
+// membawa tanggal kedaluwarsa untuk lot barunya
+func (repo *StockOpnameRepository) SubmitCounts(opnameID int, counts []model.StockCount, userID *int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenOpname(tx, opnameID); err != nil {
+		return err
+	}
+
+	for _, c := range counts {
+		var stock, price int
+		var trackExpiry bool
+		err := tx.QueryRow("SELECT stock, price, track_expiry FROM products WHERE id = $1", c.ProductID).Scan(&stock, &price, &trackExpiry)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: id %d", ErrProductNotFound, c.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := checkVariantLine(tx, c.ProductID, c.VariantID); err != nil {
+			return err
+		}
+		if c.VariantID != 0 {
+			err := tx.QueryRow("SELECT stock, COALESCE(price, $2) FROM product_variants WHERE id = $1", c.VariantID, price).Scan(&stock, &price)
+			if err != nil {
+				return err
+			}
+		}
+		// Stok produk komposit selalu berasal dari bahannya
+		isComposite, err := productHasRecipe(tx, c.ProductID)
+		if err != nil {
+			return err
+		}
+		if isComposite {
+			return fmt.Errorf("%w (product id %d)", ErrCompositeConflict, c.ProductID)
+		}
+
+		expiryDate := ""
+		if trackExpiry && c.CountedQty > stock {
+			if c.ExpiryDate == "" {
+				return fmt.Errorf("%w (product id %d)", ErrExpiryRequired, c.ProductID)
+			}
+			expiryDate = c.ExpiryDate
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO stock_opname_items (opname_id, product_id, variant_id, system_stock, counted_qty, expiry_date, unit_price, counted_by)
+			VALUES ($1, $2, NULLIF($3, 0), $4, $5, NULLIF($6, '')::date, $7, $8)
+			ON CONFLICT (opname_id, product_id, COALESCE(variant_id, 0)) DO UPDATE
+			SET system_stock = EXCLUDED.system_stock, counted_qty = EXCLUDED.counted_qty, expiry_date = EXCLUDED.expiry_date,
+				unit_price = EXCLUDED.unit_price, counted_by = EXCLUDED.counted_by, counted_at = CURRENT_TIMESTAMP`,
+			opnameID, c.ProductID, c.VariantID, stock, c.CountedQty, expiryDate, price, userID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Finalize memposting selisih setiap item sebagai adjustment di ledger stok
+// (per varian untuk produk bervarian) dan menutup sesi, semuanya dalam satu
+// transaksi database
+func (repo *StockOpnameRepository) Finalize(opnameID int, userID *int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenOpname(tx, opnameID); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(
+		`SELECT product_id, variant_id, counted_qty - system_stock, COALESCE(to_char(expiry_date, 'YYYY-MM-DD'), '')
+		FROM stock_opname_items WHERE opname_id = $1 AND counted_qty <> system_stock ORDER BY product_id, variant_id`,
+		opnameID,
+	)
+	if err != nil {
+		return err
+	}
+
+	adjustments := make([]model.StockMovement, 0)
+	for rows.Next() {
+		m := model.StockMovement{
+			Type:      model.MovementAdjustment,
+			Reason:    "stock opname",
+			Reference: fmt.Sprintf("opname:%d", opnameID),
+			UserID:    userID,
+		}
+		if err := rows.Scan(&m.ProductID, &m.VariantID, &m.Quantity, &m.ExpiryDate); err != nil {
+			rows.Close()
+			return err
+		}
+		adjustments = append(adjustments, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range adjustments {
+		// Resep bisa saja ditambahkan setelah produk dihitung
+		isComposite, err := productHasRecipe(tx, adjustments[i].ProductID)
+		if err != nil {
+			return err
+		}
+		if isComposite {
+			return fmt.Errorf("%w (product id %d)", ErrCompositeConflict, adjustments[i].ProductID)
+		}
+
+		if err := recordStockMovement(tx, &adjustments[i]); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		"UPDATE stock_opnames SET status = $1, finalized_by = $2, finalized_at = CURRENT_TIMESTAMP WHERE id = $3",
+		model.OpnameFinalized, userID, opnameID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Cancel menutup sesi tanpa memposting adjustment apa pun
+func (repo *StockOpnameRepository) Cancel(opnameID int, userID *int) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOpenOpname(tx, opnameID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"UPDATE stock_opnames SET status = $1, finalized_by = $2, finalized_at = CURRENT_TIMESTAMP WHERE id = $3",
+		model.OpnameCancelled, userID, opnameID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockOpenOpname mengunci baris sesi opname dan memastikan statusnya masih open
+func lockOpenOpname(tx *sql.Tx, opnameID int) error {
+	var status string
+	err := tx.QueryRow("SELECT status FROM stock_opnames WHERE id = $1 FOR UPDATE", opnameID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return ErrOpnameNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if status != model.OpnameOpen {
+		return ErrOpnameClosed
+	}
+
+	return nil
+}
@@ -0,0 +1,284 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"kasir-api/model"
+)
+
+var (
+	// ErrInsufficientStock dikembalikan saat pergerakan stok akan membuat stok produk negatif
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrVariantRequired dikembalikan saat mengubah stok produk bervarian tanpa menyebut variannya
+	ErrVariantRequired = errors.New("product has variants, variant_id is required")
+	// ErrVariantNotFound dikembalikan saat varian tidak ada atau bukan milik produk tersebut
+	ErrVariantNotFound = errors.New("variant not found")
+	// ErrExpiryRequired dikembalikan saat menambah stok produk track_expiry tanpa tanggal kedaluwarsa
+	ErrExpiryRequired = errors.New("product tracks expiry, expiry_date is required for incoming stock")
+	// ErrExpiredStock dikembalikan saat penjualan hanya bisa dipenuhi dari lot yang sudah kedaluwarsa
+	ErrExpiredStock = errors.New("not enough unexpired stock")
+)
+
+type StockRepository struct {
+	db *sql.DB
+}
+
+func NewStockRepository(db *sql.DB) *StockRepository {
+	return &StockRepository{db: db}
+}
+
+// CreateMovement mencatat satu pergerakan stok manual (restock, adjustment, return, waste)
+func (repo *StockRepository) CreateMovement(m *model.StockMovement) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Stok produk komposit selalu berasal dari bahannya
+	isComposite, err := productHasRecipe(tx, m.ProductID)
+	if err != nil {
+		return err
+	}
+	if isComposite {
+		return ErrCompositeConflict
+	}
+
+	if err := recordStockMovement(tx, m); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ToBaseQuantity mengonversi quantity dalam satuan unit milik produk ke satuan
+// dasar. Faktor konversinya ikut dikembalikan untuk menghitung harga per satuan dasar
+func (repo *StockRepository) ToBaseQuantity(productID int, unit string, quantity float64) (int, int, error) {
+	_, factor, err := resolveUnit(repo.db, productID, unit)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	base, err := toBaseQuantity(quantity, factor)
+	if err != nil {
+		return 0, 0, err
+	}
+	return base, factor, nil
+}
+
+// GetByProduct mengambil riwayat pergerakan stok sebuah produk, terbaru lebih dulu
+func (repo *StockRepository) GetByProduct(productID, limit, offset int) ([]model.StockMovement, int, error) {
+	var total int
+	err := repo.db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM stock_movements WHERE product_id = p.id)
+		FROM products p WHERE p.id = $1`, productID).Scan(&total)
+	if err == sql.ErrNoRows {
+		return nil, 0, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT m.id, m.product_id, m.variant_id, m.type, m.quantity, m.stock_after, m.unit_cost, m.lot_id,
+			COALESCE(to_char(l.expiry_date, 'YYYY-MM-DD'), ''), COALESCE(m.reason, ''), COALESCE(m.reference, ''), m.user_id, m.created_at
+		FROM stock_movements m
+		LEFT JOIN product_lots l ON l.id = m.lot_id
+		WHERE m.product_id = $1
+		ORDER BY m.id DESC
+		LIMIT $2 OFFSET $3`
+	rows, err := repo.db.Query(query, productID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	movements := make([]model.StockMovement, 0)
+	for rows.Next() {
+		var m model.StockMovement
+		err := rows.Scan(&m.ID, &m.ProductID, &m.VariantID, &m.Type, &m.Quantity, &m.StockAfter, &m.UnitCost, &m.LotID, &m.ExpiryDate, &m.Reason, &m.Reference, &m.UserID, &m.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, total, rows.Err()
+}
+
+// recordStockMovement mengubah products.stock sebesar m.Quantity dan menulis baris
+// ledger-nya di dalam transaksi yang sama. Semua perubahan stok (checkout, restock,
+// koreksi manual) harus lewat fungsi ini supaya stok selalu bisa ditelusuri.
+// Jika m.UnitCost diisi untuk movement yang menambah stok, cost_price produk
+// diperbarui dengan rata-rata tertimbang antara stok lama dan barang yang masuk.
+// Untuk produk bervarian, stok varian diubah dan stok induk ikut berubah
+// sebesar yang sama sehingga selalu sama dengan jumlah stok variannya.
+// Untuk produk track_expiry, lot-nya ikut diperbarui lewat applyLotMovement
+func recordStockMovement(tx *sql.Tx, m *model.StockMovement) error {
+	if m.VariantID == nil {
+		hasVariants, err := productHasVariants(tx, m.ProductID)
+		if err != nil {
+			return err
+		}
+		if hasVariants {
+			return fmt.Errorf("%w (product id %d)", ErrVariantRequired, m.ProductID)
+		}
+	}
+
+	// Semua ekspresi SET di PostgreSQL membaca nilai baris sebelum update
+	var trackExpiry bool
+	err := tx.QueryRow(`
+		UPDATE products SET
+			cost_price = CASE
+				WHEN $3::int IS NULL OR $1 <= 0 THEN cost_price
+				WHEN GREATEST(stock, 0) = 0 THEN $3::int
+				ELSE ROUND((GREATEST(stock, 0) * cost_price + $1 * $3::int)::numeric / (GREATEST(stock, 0) + $1))::int
+			END,
+			stock = stock + $1
+		WHERE id = $2
+		RETURNING stock, track_expiry`,
+		m.Quantity, m.ProductID, m.UnitCost,
+	).Scan(&m.ProductStockAfter, &trackExpiry)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	m.StockAfter = m.ProductStockAfter
+	if m.VariantID != nil {
+		err := tx.QueryRow(
+			"UPDATE product_variants SET stock = stock + $1 WHERE id = $2 AND product_id = $3 RETURNING stock",
+			m.Quantity, *m.VariantID, m.ProductID,
+		).Scan(&m.StockAfter)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%w: id %d", ErrVariantNotFound, *m.VariantID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.StockAfter < 0 {
+		return fmt.Errorf("%w for product id %d", ErrInsufficientStock, m.ProductID)
+	}
+
+	if trackExpiry {
+		if err := applyLotMovement(tx, m); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO stock_movements (product_id, variant_id, type, quantity, stock_after, unit_cost, lot_id, reason, reference, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10)
+		RETURNING id, created_at`
+	return tx.QueryRow(query, m.ProductID, m.VariantID, m.Type, m.Quantity, m.StockAfter, m.UnitCost, m.LotID, m.Reason, m.Reference, m.UserID).Scan(&m.ID, &m.CreatedAt)
+}
+
+// applyLotMovement menerapkan movement ke lot produk track_expiry. Barang masuk
+// membuat lot baru dengan m.ExpiryDate, sedangkan barang keluar mengurangi lot
+// FEFO (paling cepat kedaluwarsa lebih dulu). Penjualan tidak boleh mengambil
+// lot yang sudah kedaluwarsa; waste dan adjustment boleh
+func applyLotMovement(tx *sql.Tx, m *model.StockMovement) error {
+	if m.Quantity > 0 {
+		if m.ExpiryDate == "" {
+			return fmt.Errorf("%w (product id %d)", ErrExpiryRequired, m.ProductID)
+		}
+
+		var lotID int
+		err := tx.QueryRow(`
+			INSERT INTO product_lots (product_id, variant_id, expiry_date, quantity, remaining, unit_cost, reference)
+			VALUES ($1, $2, $3, $4, $4, $5, NULLIF($6, ''))
+			RETURNING id`,
+			m.ProductID, m.VariantID, m.ExpiryDate, m.Quantity, m.UnitCost, m.Reference,
+		).Scan(&lotID)
+		if err != nil {
+			return err
+		}
+		m.LotID = &lotID
+		return nil
+	}
+
+	expiredFilter := ""
+	if m.Type == model.MovementSale {
+		expiredFilter = " AND expiry_date >= CURRENT_DATE"
+	}
+	rows, err := tx.Query(`
+		SELECT id, remaining FROM product_lots
+		WHERE product_id = $1 AND variant_id IS NOT DISTINCT FROM $2 AND remaining > 0`+expiredFilter+`
+		ORDER BY expiry_date, received_at, id
+		FOR UPDATE`,
+		m.ProductID, m.VariantID,
+	)
+	if err != nil {
+		return err
+	}
+
+	type lotTake struct{ id, qty int }
+	takes := make([]lotTake, 0)
+	need := -m.Quantity
+	for need > 0 && rows.Next() {
+		var id, remaining int
+		if err := rows.Scan(&id, &remaining); err != nil {
+			rows.Close()
+			return err
+		}
+		take := min(remaining, need)
+		takes = append(takes, lotTake{id, take})
+		need -= take
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if need > 0 {
+		if m.Type == model.MovementSale {
+			return fmt.Errorf("%w for product id %d", ErrExpiredStock, m.ProductID)
+		}
+		return fmt.Errorf("%w for product id %d", ErrInsufficientStock, m.ProductID)
+	}
+
+	for _, t := range takes {
+		if _, err := tx.Exec("UPDATE product_lots SET remaining = remaining - $1 WHERE id = $2", t.qty, t.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// productHasVariants mengecek apakah stok produk dikelola per varian
+func productHasVariants(tx *sql.Tx, productID int) (bool, error) {
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_variants WHERE product_id = $1)", productID).Scan(&exists)
+	return exists, err
+}
+
+// checkVariantLine memastikan variantID cocok dengan produknya: wajib diisi
+// untuk produk bervarian, dan harus 0 untuk produk tanpa varian
+func checkVariantLine(tx *sql.Tx, productID, variantID int) error {
+	if variantID == 0 {
+		hasVariants, err := productHasVariants(tx, productID)
+		if err != nil {
+			return err
+		}
+		if hasVariants {
+			return fmt.Errorf("%w (product id %d)", ErrVariantRequired, productID)
+		}
+		return nil
+	}
+
+	var exists bool
+	err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM product_variants WHERE id = $1 AND product_id = $2)", variantID, productID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: id %d for product id %d", ErrVariantNotFound, variantID, productID)
+	}
+	return nil
+}
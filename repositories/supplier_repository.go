@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/model"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrSupplierNotFound dikembalikan saat supplier dengan ID tersebut tidak ada
+	ErrSupplierNotFound = errors.New("supplier not found")
+	// ErrSupplierInUse dikembalikan saat menghapus supplier yang masih punya purchase order
+	ErrSupplierInUse = errors.New("supplier still has purchase orders")
+)
+
+type SupplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+	return &SupplierRepository{db: db}
+}
+
+const supplierColumns = "id, name, COALESCE(contact_name, ''), COALESCE(phone, ''), COALESCE(email, ''), COALESCE(address, ''), COALESCE(payment_terms, ''), created_at"
+
+func scanSupplier(row interface{ Scan(...any) error }, s *model.Supplier) error {
+	return row.Scan(&s.ID, &s.Name, &s.ContactName, &s.Phone, &s.Email, &s.Address, &s.PaymentTerms, &s.CreatedAt)
+}
+
+func (repo *SupplierRepository) GetAll() ([]model.Supplier, error) {
+	rows, err := repo.db.Query("SELECT " + supplierColumns + " FROM suppliers ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]model.Supplier, 0)
+	for rows.Next() {
+		var s model.Supplier
+		if err := scanSupplier(rows, &s); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, s)
+	}
+
+	return suppliers, rows.Err()
+}
+
+func (repo *SupplierRepository) Create(s *model.Supplier) error {
+	query := `
+		INSERT INTO suppliers (name, contact_name, phone, email, address, payment_terms)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING id, created_at`
+	return repo.db.QueryRow(query, s.Name, s.ContactName, s.Phone, s.Email, s.Address, s.PaymentTerms).Scan(&s.ID, &s.CreatedAt)
+}
+
+func (repo *SupplierRepository) GetByID(id int) (*model.Supplier, error) {
+	var s model.Supplier
+	err := scanSupplier(repo.db.QueryRow("SELECT "+supplierColumns+" FROM suppliers WHERE id = $1", id), &s)
+	if err == sql.ErrNoRows {
+		return nil, ErrSupplierNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func (repo *SupplierRepository) Update(s *model.Supplier) error {
+	query := `
+		UPDATE suppliers
+		SET name = $1, contact_name = NULLIF($2, ''), phone = NULLIF($3, ''), email = NULLIF($4, ''),
+			address = NULLIF($5, ''), payment_terms = NULLIF($6, '')
+		WHERE id = $7
+		RETURNING created_at`
+	err := repo.db.QueryRow(query, s.Name, s.ContactName, s.Phone, s.Email, s.Address, s.PaymentTerms, s.ID).Scan(&s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return ErrSupplierNotFound
+	}
+	return err
+}
+
+func (repo *SupplierRepository) Delete(id int) error {
+	result, err := repo.db.Exec("DELETE FROM suppliers WHERE id = $1", id)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return ErrSupplierInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrSupplierNotFound
+	}
+
+	return nil
+}
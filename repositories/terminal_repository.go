@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"kasir-api/model"
+)
+
+type TerminalRepository struct {
+	db *sql.DB
+}
+
+func NewTerminalRepository(db *sql.DB) *TerminalRepository {
+	return &TerminalRepository{db: db}
+}
+
+func (repo *TerminalRepository) GetAll() ([]model.Terminal, error) {
+	query := "SELECT id, name, active, created_at FROM terminals ORDER BY id"
+	rows, err := repo.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terminals := make([]model.Terminal, 0)
+	for rows.Next() {
+		var t model.Terminal
+		err := rows.Scan(&t.ID, &t.Name, &t.Active, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		terminals = append(terminals, t)
+	}
+
+	return terminals, nil
+}
+
+func (repo *TerminalRepository) Create(t *model.Terminal) error {
+	query := "INSERT INTO terminals (name, key_hash) VALUES ($1, $2) RETURNING id, active, created_at"
+	return repo.db.QueryRow(query, t.Name, t.KeyHash).Scan(&t.ID, &t.Active, &t.CreatedAt)
+}
+
+func (repo *TerminalRepository) GetByKeyHash(keyHash string) (*model.Terminal, error) {
+	query := "SELECT id, name, active, created_at, key_hash FROM terminals WHERE key_hash = $1"
+
+	var t model.Terminal
+	err := repo.db.QueryRow(query, keyHash).Scan(&t.ID, &t.Name, &t.Active, &t.CreatedAt, &t.KeyHash)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("terminal not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+func (repo *TerminalRepository) SetActive(id int, active bool) error {
+	query := "UPDATE terminals SET active = $1 WHERE id = $2"
+	result, err := repo.db.Exec(query, active, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("terminal not found")
+	}
+
+	return nil
+}
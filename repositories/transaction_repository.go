@@ -2,13 +2,65 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"kasir-api/model"
+	"math"
 	"time"
 
 	"github.com/lib/pq"
 )
 
+// checkoutProduct adalah data produk yang dibutuhkan untuk memproses checkout.
+// Stock adalah stok yang bisa dijual, tanpa lot yang sudah kedaluwarsa.
+// Produk yang diarsipkan tidak bisa dijual, tapi tetap boleh dipakai sebagai bahan resep
+type checkoutProduct struct {
+	Name      string
+	Price     int
+	CostPrice int
+	Stock     int
+	MinStock  int
+	BaseUnit  string
+	Archived  bool
+}
+
+// checkoutUnit adalah satuan yang dipakai di satu baris checkout. Price nil
+// berarti harga satuan ini adalah harga satuan dasar dikali Factor
+type checkoutUnit struct {
+	Name   string
+	Factor int
+	Price  *int
+}
+
+// subtotal menghitung subtotal baris untuk quantity (boleh pecahan) dalam
+// satuan ini, dibulatkan ke rupiah terdekat
+func (u checkoutUnit) subtotal(quantity float64, basePrice, modifierPrice int) int {
+	price := basePrice * u.Factor
+	if u.Price != nil {
+		price = *u.Price
+	}
+	return int(math.Round(quantity * float64(price+modifierPrice)))
+}
+
+// checkoutVariant adalah data varian yang dibutuhkan untuk memproses checkout.
+// Price nil berarti varian memakai harga produk induk
+type checkoutVariant struct {
+	ProductID int
+	Name      string
+	Price     *int
+	Stock     int
+}
+
+var (
+	// ErrTransactionNotFound dikembalikan saat transaksi dengan ID tersebut tidak ada
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrProductNotForSale dikembalikan saat checkout memuat produk yang sudah diarsipkan
+	ErrProductNotForSale = errors.New("product is archived and cannot be sold")
+	// ErrInvalidModifierSelection dikembalikan saat modifier yang dipilih tidak ada,
+	// dipilih lebih dari sekali, atau melanggar batas min/max group-nya
+	ErrInvalidModifierSelection = errors.New("invalid modifier selection")
+)
+
 type TransactionRepository struct {
 	db *sql.DB
 }
@@ -17,82 +69,173 @@ func NewTransactionRepository(db *sql.DB) *TransactionRepository {
 	return &TransactionRepository{db: db}
 }
 
-func (repo *TransactionRepository) CreateTransaction(items []model.CheckoutItem) (*model.Transaction, error) {
+func (repo *TransactionRepository) CreateTransaction(items []model.CheckoutItem, userID *int) (*model.Transaction, error) {
 	tx, err := repo.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	// 0. Item yang dikirim dengan barcode diterjemahkan dulu ke ID produk
+	if err := resolveBarcodes(tx, items); err != nil {
+		return nil, err
+	}
+
 	// 1. Dapatkan semua ID produk untuk batch select
 	productIDs := make([]int, len(items))
-	itemMap := make(map[int]int)
 	for i, item := range items {
 		productIDs[i] = item.ProductID
-		itemMap[item.ProductID] = item.Quantity
 	}
 
-	// 2. Batch SELECT produk
-	rows, err := tx.Query(
-		"SELECT id, name, price, stock FROM products WHERE id = ANY($1)",
-		pq.Array(productIDs),
+	// 2. Batch SELECT produk. Bahan dari produk komposit di keranjang ikut
+	// di-select karena stok dan HPP-nya dibutuhkan
+	recipes, err := loadCheckoutRecipes(tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	selectIDs := append([]int{}, productIDs...)
+	for _, recipe := range recipes {
+		for _, line := range recipe {
+			selectIDs = append(selectIDs, line.IngredientID)
+		}
+	}
+
+	// Stok yang ada di lot kedaluwarsa tidak bisa dijual, jadi tidak dihitung
+	rows, err := tx.Query(`
+		SELECT p.id, p.name, p.price, p.cost_price,
+			p.stock - COALESCE((SELECT SUM(l.remaining) FROM product_lots l WHERE l.product_id = p.id AND l.expiry_date < CURRENT_DATE), 0),
+			p.min_stock, p.base_unit, p.archived_at IS NOT NULL
+		FROM products p
+		WHERE p.id = ANY($1)`,
+		pq.Array(selectIDs),
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	products := make(map[int]struct {
-		Name  string
-		Price int
-		Stock int
-	})
+	products := make(map[int]checkoutProduct)
 	for rows.Next() {
 		var id int
-		var p struct {
-			Name  string
-			Price int
-			Stock int
-		}
-		if err := rows.Scan(&id, &p.Name, &p.Price, &p.Stock); err != nil {
+		var p checkoutProduct
+		if err := rows.Scan(&id, &p.Name, &p.Price, &p.CostPrice, &p.Stock, &p.MinStock, &p.BaseUnit, &p.Archived); err != nil {
 			return nil, err
 		}
 		products[id] = p
 	}
 
-	// 3. Validasi stok dan hitung total
+	variants, hasVariants, err := loadCheckoutVariants(tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	modifierGroups, err := loadCheckoutModifiers(tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	units, err := loadCheckoutUnits(tx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Validasi stok dan hitung total. Untuk produk bervarian, harga dan stok
+	// diambil dari varian (harga induk dipakai jika varian tidak override).
+	// Harga modifier yang dipilih ditambahkan ke harga satuan. Quantity dikonversi
+	// dari satuan yang dipilih ke satuan dasar, dan boleh pecahan (mis. 0.25 kg).
+	// Produk komposit tidak punya stok sendiri: kebutuhan bahannya dijumlahkan
+	// dulu lalu dicek terhadap stok bahan, termasuk bahan yang juga dijual langsung
 	totalAmount := 0
 	details := make([]model.TransactionDetail, 0)
+	ingredientQty := make(map[int]int)
+	directQty := make(map[int]int)
 	for _, item := range items {
 		p, ok := products[item.ProductID]
 		if !ok {
-			return nil, fmt.Errorf("product id %d not found", item.ProductID)
+			return nil, fmt.Errorf("%w (product id %d)", ErrProductNotFound, item.ProductID)
+		}
+		if p.Archived {
+			return nil, fmt.Errorf("%w: %s (id: %d)", ErrProductNotForSale, p.Name, item.ProductID)
 		}
 
-		if p.Stock < item.Quantity {
-			return nil, fmt.Errorf("insufficient stock for product %s (id: %d)", p.Name, item.ProductID)
+		unit := checkoutUnit{Name: p.BaseUnit, Factor: 1}
+		if item.Unit != "" && item.Unit != p.BaseUnit {
+			u, ok := units[item.ProductID][item.Unit]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s for product %s (id: %d)", ErrUnitNotFound, item.Unit, p.Name, item.ProductID)
+			}
+			unit = u
+		}
+		quantity, err := toBaseQuantity(item.Quantity, unit.Factor)
+		if err != nil || quantity < 0 {
+			return nil, fmt.Errorf("%w: %v %s for product %s (id: %d)", ErrInvalidQuantity, item.Quantity, unit.Name, p.Name, item.ProductID)
 		}
 
-		subtotal := p.Price * item.Quantity
-		totalAmount += subtotal
+		detail := model.TransactionDetail{
+			ProductID:    item.ProductID,
+			ProductName:  p.Name,
+			Quantity:     quantity,
+			Unit:         unit.Name,
+			UnitQuantity: item.Quantity,
+			UnitCost:     p.CostPrice,
+		}
+		price, stock := p.Price, p.Stock
 
-		// Tetap update stok per baris untuk locking ROW (mencegah double sell)
-		// Namun bisa juga dioptimasi jika perlu. Dalam case POS, ini biasanya ok.
-		_, err = tx.Exec(
-			"UPDATE products SET stock = stock - $1 WHERE id = $2",
-			item.Quantity,
-			item.ProductID,
-		)
+		modifiers, modifierPrice, err := selectModifiers(item, p.Name, modifierGroups[item.ProductID])
 		if err != nil {
 			return nil, err
 		}
+		detail.Modifiers = modifiers
+
+		if recipe, ok := recipes[item.ProductID]; ok {
+			if item.VariantID != 0 {
+				return nil, fmt.Errorf("%w: id %d for product %s (id: %d)", ErrVariantNotFound, item.VariantID, p.Name, item.ProductID)
+			}
+			detail.UnitCost = 0
+			for _, line := range recipe {
+				detail.UnitCost += products[line.IngredientID].CostPrice * line.Quantity
+				ingredientQty[line.IngredientID] += line.Quantity * quantity
+			}
+			detail.Subtotal = unit.subtotal(item.Quantity, price, modifierPrice)
+			totalAmount += detail.Subtotal
+
+			details = append(details, detail)
+			continue
+		}
+
+		if item.VariantID != 0 {
+			v, ok := variants[item.VariantID]
+			if !ok || v.ProductID != item.ProductID {
+				return nil, fmt.Errorf("%w: id %d for product %s (id: %d)", ErrVariantNotFound, item.VariantID, p.Name, item.ProductID)
+			}
+			if v.Price != nil {
+				price = *v.Price
+			}
+			stock = v.Stock
+			variantID := item.VariantID
+			detail.VariantID = &variantID
+			detail.VariantName = v.Name
+		} else if hasVariants[item.ProductID] {
+			return nil, fmt.Errorf("%w: %s (id: %d)", ErrVariantRequired, p.Name, item.ProductID)
+		}
+
+		if stock < quantity {
+			return nil, fmt.Errorf("%w for product %s (id: %d)", ErrInsufficientStock, p.Name, item.ProductID)
+		}
+		if detail.VariantID == nil {
+			directQty[item.ProductID] += quantity
+		}
 
-		details = append(details, model.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: p.Name,
-			Quantity:    item.Quantity,
-			Subtotal:    subtotal,
-		})
+		detail.Subtotal = unit.subtotal(item.Quantity, price, modifierPrice)
+		totalAmount += detail.Subtotal
+
+		details = append(details, detail)
+	}
+
+	for id, qty := range ingredientQty {
+		if ing := products[id]; ing.Stock < qty+directQty[id] {
+			return nil, fmt.Errorf("%w for ingredient %s (id: %d)", ErrInsufficientStock, ing.Name, id)
+		}
 	}
 
 	// 4. INSERT transaction
@@ -106,15 +249,70 @@ func (repo *TransactionRepository) CreateTransaction(items []model.CheckoutItem)
 		return nil, err
 	}
 
-	// 5. Batch INSERT transaction details
+	// 5. Kurangi stok lewat ledger. Update per baris tetap mengunci ROW produk
+	// (mencegah double sell), dan stok negatif membatalkan seluruh transaksi
+	// Produk komposit mengurangi stok setiap bahannya, bukan stok dirinya sendiri
+	stockBefore := make(map[int]int)
+	stockAfter := make(map[int]int)
+	touched := make([]int, 0)
+	for _, d := range details {
+		movements := []model.StockMovement{{
+			ProductID: d.ProductID,
+			VariantID: d.VariantID,
+			Quantity:  -d.Quantity,
+		}}
+		if recipe, ok := recipes[d.ProductID]; ok {
+			movements = movements[:0]
+			for _, line := range recipe {
+				movements = append(movements, model.StockMovement{
+					ProductID: line.IngredientID,
+					Quantity:  -line.Quantity * d.Quantity,
+					Reason:    "ingredient of " + d.ProductName,
+				})
+			}
+		}
+
+		for _, m := range movements {
+			m.Type = model.MovementSale
+			m.Reference = fmt.Sprintf("transaction:%d", transactionID)
+			m.UserID = userID
+			if err := recordStockMovement(tx, &m); err != nil {
+				return nil, err
+			}
+
+			// Batas minimum dipantau di level produk induk (jumlah semua varian)
+			if _, ok := stockBefore[m.ProductID]; !ok {
+				stockBefore[m.ProductID] = m.ProductStockAfter - m.Quantity
+				touched = append(touched, m.ProductID)
+			}
+			stockAfter[m.ProductID] = m.ProductStockAfter
+		}
+	}
+
+	// Alert hanya dibuat saat stok baru saja melewati batas minimum, supaya
+	// checkout berikutnya untuk produk yang sama tidak mengirim alert berulang
+	alerts := make([]model.LowStockAlert, 0)
+	for _, id := range touched {
+		p, after := products[id], stockAfter[id]
+		if p.MinStock > 0 && stockBefore[id] > p.MinStock && after <= p.MinStock {
+			alerts = append(alerts, model.LowStockAlert{
+				ProductID:   id,
+				ProductName: p.Name,
+				Stock:       after,
+				MinStock:    p.MinStock,
+			})
+		}
+	}
+
+	// 6. Batch INSERT transaction details
 	// Kita bisa gunakan satu query dengan banyak VALUES
-	query := "INSERT INTO transaction_details (transaction_id, product_id, quantity, subtotal) VALUES "
+	query := "INSERT INTO transaction_details (transaction_id, product_id, variant_id, quantity, unit_name, unit_quantity, subtotal, unit_cost) VALUES "
 	values := []interface{}{}
 	for i, d := range details {
 		details[i].TransactionID = transactionID
-		n := i * 4
-		query += fmt.Sprintf("($%d, $%d, $%d, $%d),", n+1, n+2, n+3, n+4)
-		values = append(values, transactionID, d.ProductID, d.Quantity, d.Subtotal)
+		n := i * 8
+		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d),", n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8)
+		values = append(values, transactionID, d.ProductID, d.VariantID, d.Quantity, d.Unit, d.UnitQuantity, d.Subtotal, d.UnitCost)
 	}
 	query = query[:len(query)-1] // Remove trailing comma
 	query += " RETURNING id"
@@ -132,82 +330,386 @@ func (repo *TransactionRepository) CreateTransaction(items []model.CheckoutItem)
 		}
 		detailIdx++
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 7. Batch INSERT snapshot modifier yang dipilih di setiap baris
+	query = "INSERT INTO transaction_detail_modifiers (transaction_detail_id, modifier_id, group_name, name, price_delta) VALUES "
+	values = values[:0]
+	for _, d := range details {
+		for _, m := range d.Modifiers {
+			n := len(values)
+			query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d),", n+1, n+2, n+3, n+4, n+5)
+			values = append(values, d.ID, m.ModifierID, m.GroupName, m.Name, m.PriceDelta)
+		}
+	}
+	if len(values) > 0 {
+		if _, err := tx.Exec(query[:len(query)-1], values...); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
 	return &model.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		CreatedAt:   createdAt,
-		Details:     details,
+		ID:             transactionID,
+		TotalAmount:    totalAmount,
+		CreatedAt:      createdAt,
+		Details:        details,
+		LowStockAlerts: alerts,
 	}, nil
 }
 
-func (repo *TransactionRepository) GetTodaySummary() (*model.SalesSummary, error) {
-	return repo.GetSummaryByRange("", "")
+// loadCheckoutVariants mengambil semua varian dari produk-produk di keranjang,
+// sekaligus menandai produk mana yang wajib checkout per varian
+func loadCheckoutVariants(tx *sql.Tx, productIDs []int) (map[int]checkoutVariant, map[int]bool, error) {
+	rows, err := tx.Query(
+		`SELECT v.id, v.product_id, v.name, v.price,
+			v.stock - COALESCE((SELECT SUM(l.remaining) FROM product_lots l WHERE l.variant_id = v.id AND l.expiry_date < CURRENT_DATE), 0)
+		FROM product_variants v
+		WHERE v.product_id = ANY($1)`,
+		pq.Array(productIDs),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	variants := make(map[int]checkoutVariant)
+	hasVariants := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		var v checkoutVariant
+		if err := rows.Scan(&id, &v.ProductID, &v.Name, &v.Price, &v.Stock); err != nil {
+			return nil, nil, err
+		}
+		variants[id] = v
+		hasVariants[v.ProductID] = true
+	}
+
+	return variants, hasVariants, rows.Err()
 }
 
-func (repo *TransactionRepository) GetSummaryByRange(startDate, endDate string) (*model.SalesSummary, error) {
-	summary := &model.SalesSummary{}
+// checkoutModifierGroup adalah modifier group sebuah produk beserta modifiernya
+// (berdasarkan ID), untuk validasi pilihan saat checkout
+type checkoutModifierGroup struct {
+	Name      string
+	MinSelect int
+	MaxSelect int
+	Modifiers map[int]model.Modifier
+}
 
-	// Query Total Revenue & Total Transaksi
-	queryTotal := `SELECT COALESCE(SUM(total_amount), 0), COUNT(id) FROM transactions WHERE 1=1`
-	argsTotal := []interface{}{}
-	placeholderIdx := 1
+// loadCheckoutModifiers mengambil modifier group dari produk-produk di keranjang
+func loadCheckoutModifiers(tx *sql.Tx, productIDs []int) (map[int][]checkoutModifierGroup, error) {
+	rows, err := tx.Query(`
+		SELECT g.id, g.product_id, g.name, g.min_select, g.max_select, m.id, m.name, m.price_delta
+		FROM modifier_groups g
+		JOIN modifiers m ON m.group_id = g.id
+		WHERE g.product_id = ANY($1)
+		ORDER BY g.id, m.id`,
+		pq.Array(productIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if startDate != "" {
-		queryTotal += fmt.Sprintf(" AND created_at >= $%d", placeholderIdx)
-		argsTotal = append(argsTotal, startDate)
-		placeholderIdx++
+	groups := make(map[int][]checkoutModifierGroup)
+	lastGroupID := 0
+	for rows.Next() {
+		var groupID, productID int
+		var g checkoutModifierGroup
+		var m model.Modifier
+		if err := rows.Scan(&groupID, &productID, &g.Name, &g.MinSelect, &g.MaxSelect, &m.ID, &m.Name, &m.PriceDelta); err != nil {
+			return nil, err
+		}
+
+		if groupID != lastGroupID {
+			g.Modifiers = make(map[int]model.Modifier)
+			groups[productID] = append(groups[productID], g)
+			lastGroupID = groupID
+		}
+		productGroups := groups[productID]
+		productGroups[len(productGroups)-1].Modifiers[m.ID] = m
 	}
-	if endDate != "" {
-		// Menambahkan 1 hari ke end_date agar mencakup seluruh hari tersebut (inclusive)
-		// Atau menggunakan operator <= dengan format 'YYYY-MM-DD 23:59:59'
-		queryTotal += fmt.Sprintf(" AND created_at <= $%d", placeholderIdx)
-		argsTotal = append(argsTotal, endDate+" 23:59:59")
-		placeholderIdx++
-	} else if startDate == "" {
-		// Default to today if no range provided
-		queryTotal += " AND created_at::date = CURRENT_DATE"
+
+	return groups, rows.Err()
+}
+
+// selectModifiers memvalidasi modifier yang dipilih untuk satu baris checkout
+// terhadap batas min/max setiap group produknya, lalu mengembalikan snapshot
+// modifier dan total tambahan harga per unit
+func selectModifiers(item model.CheckoutItem, productName string, groups []checkoutModifierGroup) ([]model.TransactionDetailModifier, int, error) {
+	var selected []model.TransactionDetailModifier
+	counts := make([]int, len(groups))
+	seen := make(map[int]bool, len(item.ModifierIDs))
+	priceDelta := 0
+
+	for _, id := range item.ModifierIDs {
+		if seen[id] {
+			return nil, 0, fmt.Errorf("%w: modifier id %d selected more than once for product %s (id: %d)", ErrInvalidModifierSelection, id, productName, item.ProductID)
+		}
+		seen[id] = true
+
+		found := false
+		for i, g := range groups {
+			m, ok := g.Modifiers[id]
+			if !ok {
+				continue
+			}
+			modifierID := id
+			selected = append(selected, model.TransactionDetailModifier{
+				ModifierID: &modifierID,
+				GroupName:  g.Name,
+				Name:       m.Name,
+				PriceDelta: m.PriceDelta,
+			})
+			counts[i]++
+			priceDelta += m.PriceDelta
+			found = true
+			break
+		}
+		if !found {
+			return nil, 0, fmt.Errorf("%w: modifier id %d not found for product %s (id: %d)", ErrInvalidModifierSelection, id, productName, item.ProductID)
+		}
+	}
+
+	for i, g := range groups {
+		if counts[i] < g.MinSelect || counts[i] > g.MaxSelect {
+			return nil, 0, fmt.Errorf("%w: modifier group %s of product %s (id: %d) requires %d to %d selection(s)", ErrInvalidModifierSelection, g.Name, productName, item.ProductID, g.MinSelect, g.MaxSelect)
+		}
 	}
 
-	err := repo.db.QueryRow(queryTotal, argsTotal...).Scan(&summary.TotalRevenue, &summary.TotalTransaksi)
+	return selected, priceDelta, nil
+}
+
+// loadCheckoutUnits mengambil satuan alternatif dari produk-produk di keranjang
+func loadCheckoutUnits(tx *sql.Tx, productIDs []int) (map[int]map[string]checkoutUnit, error) {
+	rows, err := tx.Query(
+		"SELECT product_id, name, factor, price FROM product_units WHERE product_id = ANY($1)",
+		pq.Array(productIDs),
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	// Query Produk Terlaris
-	queryTopProduct := `
-		SELECT p.name, SUM(td.quantity) as total_qty
+	units := make(map[int]map[string]checkoutUnit)
+	for rows.Next() {
+		var productID int
+		var u checkoutUnit
+		if err := rows.Scan(&productID, &u.Name, &u.Factor, &u.Price); err != nil {
+			return nil, err
+		}
+		if units[productID] == nil {
+			units[productID] = make(map[string]checkoutUnit)
+		}
+		units[productID][u.Name] = u
+	}
+
+	return units, rows.Err()
+}
+
+// recipeLine adalah satu bahan dari resep produk komposit
+type recipeLine struct {
+	IngredientID int
+	Quantity     int
+}
+
+// loadCheckoutRecipes mengambil resep dari produk-produk komposit di keranjang
+func loadCheckoutRecipes(tx *sql.Tx, productIDs []int) (map[int][]recipeLine, error) {
+	rows, err := tx.Query(
+		"SELECT product_id, ingredient_id, quantity FROM product_recipes WHERE product_id = ANY($1) ORDER BY product_id, ingredient_id",
+		pq.Array(productIDs),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recipes := make(map[int][]recipeLine)
+	for rows.Next() {
+		var productID int
+		var line recipeLine
+		if err := rows.Scan(&productID, &line.IngredientID, &line.Quantity); err != nil {
+			return nil, err
+		}
+		recipes[productID] = append(recipes[productID], line)
+	}
+
+	return recipes, rows.Err()
+}
+
+// resolveBarcodes mengisi ProductID untuk item checkout yang hanya membawa barcode
+func resolveBarcodes(tx *sql.Tx, items []model.CheckoutItem) error {
+	codes := make([]string, 0)
+	for _, item := range items {
+		if item.ProductID == 0 && item.Barcode != "" {
+			codes = append(codes, item.Barcode)
+		}
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query("SELECT product_id, code FROM product_barcodes WHERE code = ANY($1)", pq.Array(codes))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	productByCode := make(map[string]int, len(codes))
+	for rows.Next() {
+		var productID int
+		var code string
+		if err := rows.Scan(&productID, &code); err != nil {
+			return err
+		}
+		productByCode[code] = productID
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range items {
+		if items[i].ProductID != 0 || items[i].Barcode == "" {
+			continue
+		}
+		productID, ok := productByCode[items[i].Barcode]
+		if !ok {
+			return fmt.Errorf("%w (barcode %s)", ErrProductNotFound, items[i].Barcode)
+		}
+		items[i].ProductID = productID
+	}
+
+	return nil
+}
+
+// GetByID mengambil transaksi beserta detail dan modifier yang dipilih, untuk cetak ulang struk
+func (repo *TransactionRepository) GetByID(id int) (*model.Transaction, error) {
+	t := model.Transaction{ID: id}
+	err := repo.db.QueryRow("SELECT total_amount, created_at FROM transactions WHERE id = $1", id).Scan(&t.TotalAmount, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := repo.db.Query(`
+		SELECT td.id, td.product_id, p.name, td.variant_id, COALESCE(v.name, ''), td.quantity,
+			COALESCE(td.unit_name, p.base_unit), COALESCE(td.unit_quantity, td.quantity), td.subtotal, td.unit_cost
 		FROM transaction_details td
 		JOIN products p ON td.product_id = p.id
-		JOIN transactions t ON td.transaction_id = t.id
-		WHERE 1=1`
+		LEFT JOIN product_variants v ON td.variant_id = v.id
+		WHERE td.transaction_id = $1
+		ORDER BY td.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	argsTop := []interface{}{}
-	placeholderIdx = 1
+	t.Details = make([]model.TransactionDetail, 0)
+	detailIdx := make(map[int]int)
+	for rows.Next() {
+		d := model.TransactionDetail{TransactionID: id}
+		if err := rows.Scan(&d.ID, &d.ProductID, &d.ProductName, &d.VariantID, &d.VariantName, &d.Quantity, &d.Unit, &d.UnitQuantity, &d.Subtotal, &d.UnitCost); err != nil {
+			return nil, err
+		}
+		detailIdx[d.ID] = len(t.Details)
+		t.Details = append(t.Details, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
+	modRows, err := repo.db.Query(`
+		SELECT tdm.transaction_detail_id, tdm.modifier_id, tdm.group_name, tdm.name, tdm.price_delta
+		FROM transaction_detail_modifiers tdm
+		JOIN transaction_details td ON tdm.transaction_detail_id = td.id
+		WHERE td.transaction_id = $1
+		ORDER BY tdm.id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer modRows.Close()
+
+	for modRows.Next() {
+		var detailID int
+		var m model.TransactionDetailModifier
+		if err := modRows.Scan(&detailID, &m.ModifierID, &m.GroupName, &m.Name, &m.PriceDelta); err != nil {
+			return nil, err
+		}
+		d := &t.Details[detailIdx[detailID]]
+		d.Modifiers = append(d.Modifiers, m)
+	}
+
+	return &t, modRows.Err()
+}
+
+func (repo *TransactionRepository) GetTodaySummary() (*model.SalesSummary, error) {
+	return repo.GetSummaryByRange("", "")
+}
+
+// reportDateFilter membuat kondisi WHERE untuk rentang tanggal laporan pada kolom
+// timestamp tertentu. Tanpa start dan end date, laporan default ke hari ini
+func reportDateFilter(column, startDate, endDate string, args []interface{}) (string, []interface{}) {
+	where := ""
 	if startDate != "" {
-		queryTopProduct += fmt.Sprintf(" AND t.created_at >= $%d", placeholderIdx)
-		argsTop = append(argsTop, startDate)
-		placeholderIdx++
+		args = append(args, startDate)
+		where += fmt.Sprintf(" AND %s >= $%d", column, len(args))
 	}
 	if endDate != "" {
-		queryTopProduct += fmt.Sprintf(" AND t.created_at <= $%d", placeholderIdx)
-		argsTop = append(argsTop, endDate+" 23:59:59")
-		placeholderIdx++
+		// Menambahkan jam 23:59:59 agar mencakup seluruh hari end_date (inclusive)
+		args = append(args, endDate+" 23:59:59")
+		where += fmt.Sprintf(" AND %s <= $%d", column, len(args))
 	} else if startDate == "" {
-		queryTopProduct += " AND t.created_at::date = CURRENT_DATE"
+		// Default to today if no range provided
+		where += fmt.Sprintf(" AND %s::date = CURRENT_DATE", column)
 	}
+	return where, args
+}
+
+func (repo *TransactionRepository) GetSummaryByRange(startDate, endDate string) (*model.SalesSummary, error) {
+	summary := &model.SalesSummary{}
+
+	// Query Total Revenue & Total Transaksi
+	dateFilter, args := reportDateFilter("created_at", startDate, endDate, nil)
+	queryTotal := `SELECT COALESCE(SUM(total_amount), 0), COUNT(id) FROM transactions WHERE 1=1` + dateFilter
 
-	queryTopProduct += `
+	err := repo.db.QueryRow(queryTotal, args...).Scan(&summary.TotalRevenue, &summary.TotalTransaksi)
+	if err != nil {
+		return nil, err
+	}
+
+	// Query HPP (COGS) dari snapshot unit_cost di transaction_details
+	dateFilter, args = reportDateFilter("t.created_at", startDate, endDate, nil)
+	queryCOGS := `
+		SELECT COALESCE(SUM(td.quantity * td.unit_cost), 0)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE 1=1` + dateFilter
+
+	err = repo.db.QueryRow(queryCOGS, args...).Scan(&summary.TotalCOGS)
+	if err != nil {
+		return nil, err
+	}
+
+	// Query Produk Terlaris
+	queryTopProduct := `
+		SELECT p.name, SUM(td.quantity) as total_qty
+		FROM transaction_details td
+		JOIN products p ON td.product_id = p.id
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE 1=1` + dateFilter + `
 		GROUP BY p.name
 		ORDER BY total_qty DESC
 		LIMIT 1`
 
-	err = repo.db.QueryRow(queryTopProduct, argsTop...).Scan(&summary.ProdukTerlaris.Nama, &summary.ProdukTerlaris.QtyTerjual)
+	err = repo.db.QueryRow(queryTopProduct, args...).Scan(&summary.ProdukTerlaris.Nama, &summary.ProdukTerlaris.QtyTerjual)
 	if err == sql.ErrNoRows {
 		summary.ProdukTerlaris.Nama = "-"
 		summary.ProdukTerlaris.QtyTerjual = 0
@@ -217,3 +719,87 @@ func (repo *TransactionRepository) GetSummaryByRange(startDate, endDate string)
 
 	return summary, nil
 }
+
+// GetProfitByProduct mengambil pendapatan dan HPP per produk dalam rentang tanggal
+func (repo *TransactionRepository) GetProfitByProduct(startDate, endDate string) ([]model.ProfitLine, error) {
+	dateFilter, args := reportDateFilter("t.created_at", startDate, endDate, nil)
+	query := `
+		SELECT p.id, 0, p.name, SUM(td.quantity), SUM(td.subtotal), SUM(td.quantity * td.unit_cost)
+		FROM transaction_details td
+		JOIN products p ON td.product_id = p.id
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE 1=1` + dateFilter + `
+		GROUP BY p.id, p.name
+		ORDER BY SUM(td.subtotal) - SUM(td.quantity * td.unit_cost) DESC, p.id`
+	return repo.queryProfitLines(query, args)
+}
+
+// GetProfitByVariant mengambil pendapatan dan HPP per varian dalam rentang
+// tanggal. Angka ini juga sudah termasuk dalam baris produk induknya
+func (repo *TransactionRepository) GetProfitByVariant(startDate, endDate string) ([]model.ProfitLine, error) {
+	dateFilter, args := reportDateFilter("t.created_at", startDate, endDate, nil)
+	query := `
+		SELECT v.id, v.product_id, p.name || ' - ' || v.name, SUM(td.quantity), SUM(td.subtotal), SUM(td.quantity * td.unit_cost)
+		FROM transaction_details td
+		JOIN product_variants v ON td.variant_id = v.id
+		JOIN products p ON td.product_id = p.id
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE 1=1` + dateFilter + `
+		GROUP BY v.id, v.product_id, p.name, v.name
+		ORDER BY SUM(td.subtotal) - SUM(td.quantity * td.unit_cost) DESC, v.id`
+	return repo.queryProfitLines(query, args)
+}
+
+// GetProfitByCategory mengambil pendapatan dan HPP per kategori dalam rentang
+// tanggal. Produk tanpa kategori dikelompokkan dengan ID 0
+func (repo *TransactionRepository) GetProfitByCategory(startDate, endDate string) ([]model.ProfitLine, error) {
+	dateFilter, args := reportDateFilter("t.created_at", startDate, endDate, nil)
+	query := `
+		SELECT COALESCE(c.id, 0), 0, COALESCE(c.name, 'Tanpa Kategori'), SUM(td.quantity), SUM(td.subtotal), SUM(td.quantity * td.unit_cost)
+		FROM transaction_details td
+		JOIN products p ON td.product_id = p.id
+		LEFT JOIN categories c ON p.category_id = c.id
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE 1=1` + dateFilter + `
+		GROUP BY COALESCE(c.id, 0), COALESCE(c.name, 'Tanpa Kategori')
+		ORDER BY SUM(td.subtotal) - SUM(td.quantity * td.unit_cost) DESC, 1`
+	return repo.queryProfitLines(query, args)
+}
+
+// GetProfitByModifier mengambil pendapatan dari modifier berbayar dalam rentang
+// tanggal. Modifier tidak punya HPP sendiri, dan pendapatannya sudah termasuk
+// dalam baris produknya. Harga modifier berlaku per satuan yang diinput saat
+// checkout, jadi jumlahnya memakai unit_quantity, bukan quantity satuan dasar
+func (repo *TransactionRepository) GetProfitByModifier(startDate, endDate string) ([]model.ProfitLine, error) {
+	dateFilter, args := reportDateFilter("t.created_at", startDate, endDate, nil)
+	query := `
+		SELECT COALESCE(tdm.modifier_id, 0), td.product_id, tdm.group_name || ': ' || tdm.name,
+			ROUND(SUM(COALESCE(td.unit_quantity, td.quantity)))::int,
+			ROUND(SUM(COALESCE(td.unit_quantity, td.quantity) * tdm.price_delta))::int, 0
+		FROM transaction_detail_modifiers tdm
+		JOIN transaction_details td ON tdm.transaction_detail_id = td.id
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE 1=1` + dateFilter + `
+		GROUP BY COALESCE(tdm.modifier_id, 0), td.product_id, tdm.group_name, tdm.name
+		ORDER BY 5 DESC, 4 DESC, 1`
+	return repo.queryProfitLines(query, args)
+}
+
+func (repo *TransactionRepository) queryProfitLines(query string, args []interface{}) ([]model.ProfitLine, error) {
+	rows, err := repo.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]model.ProfitLine, 0)
+	for rows.Next() {
+		var l model.ProfitLine
+		if err := rows.Scan(&l.ID, &l.ProductID, &l.Name, &l.QtySold, &l.Revenue, &l.COGS); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+
+	return lines, rows.Err()
+}
@@ -17,7 +17,7 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 
 // === Repo Functions ===
 func (repo *UserRepository) GetAll() ([]model.User, error) {
-	query := "SELECT id, name, email FROM users"
+	query := "SELECT id, name, email, role, active, locked_until, totp_enabled FROM users ORDER BY id"
 	rows, err := repo.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -28,7 +28,7 @@ func (repo *UserRepository) GetAll() ([]model.User, error) {
 	users := make([]model.User, 0)
 	for rows.Next() {
 		var u model.User
-		err := rows.Scan(&u.ID, &u.Name, &u.Email)
+		err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Role, &u.Active, &u.LockedUntil, &u.TwoFactorEnabled)
 		if err != nil {
 			return nil, err
 		}
@@ -38,11 +38,16 @@ func (repo *UserRepository) GetAll() ([]model.User, error) {
 	return users, err
 }
 
+func (repo *UserRepository) Create(user *model.User) error {
+	query := "INSERT INTO users (name, email, password, role) VALUES ($1, $2, $3, $4) RETURNING id, active"
+	return repo.db.QueryRow(query, user.Name, user.Email, user.Password, user.Role).Scan(&user.ID, &user.Active)
+}
+
 func (repo *UserRepository) GetByID(id int) (*model.User, error) {
-	query := "SELECT id, name, email FROM users WHERE id = $1"
+	query := "SELECT id, name, email, role, active, locked_until, totp_enabled FROM users WHERE id = $1"
 
 	var u model.User
-	err := repo.db.QueryRow(query, id).Scan(&u.ID, &u.Name, &u.Email)
+	err := repo.db.QueryRow(query, id).Scan(&u.ID, &u.Name, &u.Email, &u.Role, &u.Active, &u.LockedUntil, &u.TwoFactorEnabled)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("User tidak ditemukan")
 	}
@@ -72,6 +77,89 @@ func (repo *UserRepository) Update(user *model.User) error {
 	return nil
 }
 
+func (repo *UserRepository) UpdateRole(id int, role string) error {
+	query := "UPDATE users SET role = $1 WHERE id = $2"
+	result, err := repo.db.Exec(query, role, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// Unlock membuka kunci akun yang terkunci karena gagal login berulang
+func (repo *UserRepository) Unlock(id int) error {
+	query := "UPDATE users SET failed_login_attempts = 0, locked_until = NULL WHERE id = $1"
+	result, err := repo.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// SetActive mengaktifkan atau menonaktifkan user. Saat dinonaktifkan,
+// semua refresh token milik user ikut di-revoke dalam transaksi yang sama
+func (repo *UserRepository) SetActive(id int, active bool) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE users SET active = $1 WHERE id = $2", active, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	if !active {
+		_, err = tx.Exec("UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL", id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// IsActive mengecek apakah user masih ada dan aktif. User yang sudah dihapus
+// dianggap tidak aktif
+func (repo *UserRepository) IsActive(id int) (bool, error) {
+	var active bool
+	err := repo.db.QueryRow("SELECT active FROM users WHERE id = $1", id).Scan(&active)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return active, err
+}
+
 func (repo *UserRepository) Delete(id int) error {
 	query := "DELETE FROM users WHERE id = $1"
 	result, err := repo.db.Exec(query, id)
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+	"log"
+	"slices"
+	"time"
+)
+
+// apiKeyPrefix memudahkan API key dikenali (misal oleh secret scanner) dan dibedakan dari JWT
+const apiKeyPrefix = "kasir_"
+
+var ErrInvalidAPIKey = errors.New("invalid, expired or revoked api key")
+
+type APIKeyService struct {
+	repo *repositories.APIKeyRepository
+}
+
+func NewAPIKeyService(repo *repositories.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+func (s *APIKeyService) GetAll() ([]model.APIKey, error) {
+	return s.repo.GetAll()
+}
+
+func (s *APIKeyService) Create(createdBy int, req model.CreateAPIKeyRequest) (*model.CreateAPIKeyResponse, error) {
+	for _, scope := range req.Scopes {
+		if !slices.Contains(model.APIKeyScopes, scope) {
+			return nil, fmt.Errorf("invalid scope %q, allowed scopes: %v", scope, model.APIKeyScopes)
+		}
+	}
+
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + secret
+
+	apiKey := model.APIKey{
+		Name:      req.Name,
+		Prefix:    key[:len(apiKeyPrefix)+8],
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		KeyHash:   utils.HashToken(key),
+	}
+	if createdBy != 0 {
+		apiKey.CreatedBy = &createdBy
+	}
+
+	if err := s.repo.Create(&apiKey); err != nil {
+		return nil, err
+	}
+
+	return &model.CreateAPIKeyResponse{APIKey: &apiKey, Key: key}, nil
+}
+
+// Validate mengecek API key dari header request dan mengembalikan datanya jika masih berlaku
+func (s *APIKeyService) Validate(key string) (*model.APIKey, error) {
+	apiKey, err := s.repo.GetByKeyHash(utils.HashToken(key))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.repo.TouchLastUsed(apiKey.ID); err != nil {
+		log.Println("failed to update api key last_used_at:", err)
+	}
+
+	return apiKey, nil
+}
+
+func (s *APIKeyService) Revoke(id int) error {
+	return s.repo.Revoke(id)
+}
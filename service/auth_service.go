@@ -2,18 +2,79 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"kasir-api/model"
+	"kasir-api/notifier"
 	"kasir-api/repositories"
 	"kasir-api/utils"
+	"log"
+	"slices"
+	"strings"
+	"time"
 )
 
+const (
+	accessTokenTTL   = 15 * time.Minute
+	refreshTokenTTL  = 7 * 24 * time.Hour
+	passwordResetTTL = 30 * time.Minute
+	pinTokenTTL      = 1 * time.Hour
+
+	twoFactorChallengeTTL = 5 * time.Minute
+	twoFactorPurpose      = "2fa_challenge"
+	twoFactorIssuer       = "Kasir API"
+	backupCodeCount       = 10
+
+	// Setiap kelipatan maxFailedLogins gagal berturut-turut, akun dikunci.
+	// Durasi lock berlipat ganda setiap kali terkunci lagi, maksimal maxLockDuration
+	maxFailedLogins      = 5
+	baseLockDuration     = 15 * time.Minute
+	maxLockDuration      = 24 * time.Hour
+	maxFailedLoginsPerIP = 20
+	ipAttemptWindow      = 15 * time.Minute
+)
+
+var (
+	ErrInvalidCredentials   = errors.New("invalid credentials")
+	ErrAccountLocked        = errors.New("account is temporarily locked due to too many failed login attempts")
+	ErrTooManyAttempts      = errors.New("too many failed login attempts, please try again later")
+	ErrInvalidRefreshToken  = errors.New("invalid or expired refresh token")
+	ErrInvalidResetToken    = errors.New("invalid or expired password reset token")
+	ErrWrongPassword        = errors.New("password is incorrect")
+	ErrInvalidTerminal      = errors.New("terminal is not registered or inactive")
+	ErrInvalidChallenge     = errors.New("invalid or expired two-factor challenge")
+	ErrAccountInactive      = errors.New("account has been deactivated")
+	ErrRegistrationClosed   = errors.New("public registration is closed, please contact an administrator")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor authentication code")
+)
+
+// AuthOptions berisi konfigurasi AuthService yang diambil dari environment
+type AuthOptions struct {
+	Secret string
+	// TwoFactorRoles adalah daftar role yang wajib mengaktifkan 2FA
+	TwoFactorRoles []string
+	// AllowRegistration mengizinkan registrasi publik lewat /api/auth/register
+	AllowRegistration bool
+}
+
 type AuthService struct {
-	repo   *repositories.AuthRepository
-	secret string
+	repo         *repositories.AuthRepository
+	terminalRepo *repositories.TerminalRepository
+	notifier     notifier.Notifier
+	secret       string
+
+	twoFactorRoles    []string
+	allowRegistration bool
 }
 
-func NewAuthService(repo *repositories.AuthRepository, secret string) *AuthService {
-	return &AuthService{repo: repo, secret: secret}
+func NewAuthService(repo *repositories.AuthRepository, terminalRepo *repositories.TerminalRepository, notifier notifier.Notifier, opts AuthOptions) *AuthService {
+	return &AuthService{
+		repo:              repo,
+		terminalRepo:      terminalRepo,
+		notifier:          notifier,
+		secret:            opts.Secret,
+		twoFactorRoles:    opts.TwoFactorRoles,
+		allowRegistration: opts.AllowRegistration,
+	}
 }
 
 func (srvc *AuthService) Register(name, email, password string) (*model.User, error) {
@@ -28,25 +89,572 @@ func (srvc *AuthService) Register(name, email, password string) (*model.User, er
 		Password: hashed,
 	}
 
-	err = srvc.repo.CreateUser(&user)
-	return &user, err
+	// User pertama yang mendaftar otomatis menjadi owner, selanjutnya cashier.
+	// Saat registrasi publik ditutup, hanya user pertama (owner) yang boleh register
+	err = srvc.repo.RegisterUser(&user, func(count int) error {
+		if count == 0 {
+			user.Role = model.RoleOwner
+			return nil
+		}
+		if !srvc.allowRegistration {
+			return ErrRegistrationClosed
+		}
+		user.Role = model.RoleCashier
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
-func (srvc *AuthService) Login(email, password string) (*model.User, string, error) {
+// Login memverifikasi email dan password. Setiap percobaan dicatat ke login_attempts,
+// dan percobaan gagal berulang akan mengunci akun atau memblokir IP sementara
+func (srvc *AuthService) Login(email, password, ip string) (*model.LoginResponse, error) {
+	if err := srvc.checkIPThrottle(email, ip); err != nil {
+		return nil, err
+	}
+
 	user, err := srvc.repo.GetUserByEmail(email)
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		srvc.recordLoginAttempt(email, ip, false, "unknown_email")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		srvc.recordLoginAttempt(email, ip, false, "account_locked")
+		return nil, ErrAccountLocked
 	}
 
 	err = utils.CheckPassword(user.Password, password)
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		srvc.recordLoginAttempt(email, ip, false, "invalid_password")
+		if err := srvc.registerFailedLogin(user.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.Active {
+		srvc.recordLoginAttempt(email, ip, false, "account_inactive")
+		return nil, ErrAccountInactive
+	}
+
+	// Counter gagal login baru di-reset setelah kode 2FA juga benar, supaya kode
+	// 2FA tidak bisa di-brute-force dengan login ulang memakai password yang benar
+	if user.TwoFactorEnabled {
+		return srvc.twoFactorChallenge(user)
+	}
+
+	if err := srvc.repo.ResetFailedLogins(user.ID); err != nil {
+		return nil, err
+	}
+	srvc.recordLoginAttempt(email, ip, true, "")
+
+	if srvc.twoFactorRequired(user.Role) {
+		return srvc.twoFactorSetupToken(user)
+	}
+
+	return srvc.issueTokens(user)
+}
+
+// VerifyTwoFactor adalah langkah kedua login untuk user yang mengaktifkan 2FA
+func (srvc *AuthService) VerifyTwoFactor(challengeToken, code, ip string) (*model.LoginResponse, error) {
+	claims, err := utils.ParseToken(challengeToken, srvc.secret)
+	if err != nil || claims.Purpose != twoFactorPurpose {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := srvc.repo.GetUserByID(claims.UserID)
+	if err != nil || !user.TwoFactorEnabled {
+		return nil, ErrInvalidChallenge
+	}
+	if !user.Active {
+		return nil, ErrAccountInactive
+	}
+
+	if err := srvc.checkIPThrottle(user.Email, ip); err != nil {
+		return nil, err
+	}
+
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		srvc.recordLoginAttempt(user.Email, ip, false, "account_locked")
+		return nil, ErrAccountLocked
+	}
+
+	valid, err := srvc.checkTwoFactorCode(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		srvc.recordLoginAttempt(user.Email, ip, false, "invalid_2fa_code")
+		if err := srvc.registerFailedLogin(user.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	if err := srvc.repo.ResetFailedLogins(user.ID); err != nil {
+		return nil, err
+	}
+	srvc.recordLoginAttempt(user.Email, ip, true, "2fa")
+
+	return srvc.issueTokens(user)
+}
+
+// SetupTwoFactor membuat secret TOTP baru untuk didaftarkan ke aplikasi authenticator.
+// 2FA belum aktif sampai user mengonfirmasi lewat EnableTwoFactor
+func (srvc *AuthService) SetupTwoFactor(userID int) (*model.TwoFactorSetupResponse, error) {
+	user, err := srvc.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := srvc.repo.SetTOTPSecret(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &model.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPAuthURI(twoFactorIssuer, user.Email, secret),
+	}, nil
+}
+
+// EnableTwoFactor mengaktifkan 2FA setelah user membuktikan authenticator-nya
+// menghasilkan kode yang benar, lalu mengembalikan backup code sekali pakai
+func (srvc *AuthService) EnableTwoFactor(userID int, code string) (*model.TwoFactorEnableResponse, error) {
+	user, err := srvc.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor authentication has not been set up")
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	used, err := srvc.repo.UseTOTPStep(user.ID, step)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	for i := range codes {
+		raw, err := utils.GenerateRandomToken(4)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = raw[:4] + "-" + raw[4:]
+		hashes[i] = utils.HashToken(raw)
+	}
+
+	if err := srvc.repo.EnableTOTP(user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &model.TwoFactorEnableResponse{BackupCodes: codes}, nil
+}
+
+// DisableTwoFactor mematikan 2FA. Tidak bisa dilakukan jika role user wajib 2FA
+func (srvc *AuthService) DisableTwoFactor(userID int, password, code string) error {
+	user, err := srvc.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	if srvc.twoFactorRequired(user.Role) {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	if err := utils.CheckPassword(user.Password, password); err != nil {
+		return ErrWrongPassword
+	}
+
+	valid, err := srvc.checkTwoFactorCode(user, code)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return srvc.repo.DisableTOTP(user.ID)
+}
+
+func (srvc *AuthService) twoFactorRequired(role string) bool {
+	return slices.Contains(srvc.twoFactorRoles, role)
+}
+
+// checkTwoFactorCode menerima kode TOTP atau backup code (yang langsung ditandai terpakai)
+func (srvc *AuthService) checkTwoFactorCode(user *model.User, code string) (bool, error) {
+	// Periode kode TOTP dicatat, supaya kode yang sama tidak bisa dipakai ulang
+	// selama masih dalam masa berlakunya
+	if step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		return srvc.repo.UseTOTPStep(user.ID, step)
+	}
+
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	return srvc.repo.UseBackupCode(user.ID, utils.HashToken(normalized))
+}
+
+func (srvc *AuthService) twoFactorChallenge(user *model.User) (*model.LoginResponse, error) {
+	token, err := utils.GenerateToken(utils.Claims{
+		UserID:  user.ID,
+		Purpose: twoFactorPurpose,
+	}, srvc.secret, twoFactorChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresIn:         int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// twoFactorSetupToken dipakai saat role user wajib 2FA tapi belum mengaktifkannya.
+// Token yang diberikan hanya bisa dipakai untuk endpoint setup 2FA
+func (srvc *AuthService) twoFactorSetupToken(user *model.User) (*model.LoginResponse, error) {
+	token, err := utils.GenerateToken(utils.Claims{
+		UserID: user.ID,
+		Role:   user.Role,
+		Scopes: []string{model.PermTwoFactor},
+	}, srvc.secret, accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	return &model.LoginResponse{
+		User:                   user,
+		Token:                  token,
+		ExpiresIn:              int(accessTokenTTL.Seconds()),
+		TwoFactorSetupRequired: true,
+	}, nil
+}
+
+// PinLogin adalah login cepat untuk kasir di terminal yang sudah didaftarkan.
+// Token yang dihasilkan berumur pendek, tanpa refresh token, hanya berlaku untuk
+// permission POSScopes, dan hanya bisa dipakai bersama key terminal yang sama
+func (srvc *AuthService) PinLogin(terminalKey string, userID int, pin, ip string) (*model.LoginResponse, error) {
+	identifier := fmt.Sprintf("user#%d", userID)
+	if err := srvc.checkIPThrottle(identifier, ip); err != nil {
+		return nil, err
+	}
+
+	terminal, err := srvc.terminalRepo.GetByKeyHash(utils.HashToken(terminalKey))
+	if err != nil || !terminal.Active {
+		srvc.recordLoginAttempt(identifier, ip, false, "invalid_terminal")
+		return nil, ErrInvalidTerminal
+	}
+
+	user, err := srvc.repo.GetUserByID(userID)
+	if err != nil {
+		srvc.recordLoginAttempt(identifier, ip, false, "unknown_user")
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		srvc.recordLoginAttempt(user.Email, ip, false, "account_locked")
+		return nil, ErrAccountLocked
+	}
+
+	if user.PinHash == "" || utils.CheckPassword(user.PinHash, pin) != nil {
+		srvc.recordLoginAttempt(user.Email, ip, false, "invalid_pin")
+		if err := srvc.registerFailedLogin(user.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.Active {
+		srvc.recordLoginAttempt(user.Email, ip, false, "account_inactive")
+		return nil, ErrAccountInactive
+	}
+
+	if err := srvc.repo.ResetFailedLogins(user.ID); err != nil {
+		return nil, err
+	}
+	srvc.recordLoginAttempt(user.Email, ip, true, "pin_login")
+
+	token, err := utils.GenerateToken(utils.Claims{
+		UserID:          user.ID,
+		Role:            user.Role,
+		Scopes:          model.POSScopes,
+		TerminalID:      terminal.ID,
+		TerminalKeyHash: terminal.KeyHash,
+	}, srvc.secret, pinTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Password = ""
+	return &model.LoginResponse{
+		User:      user,
+		Token:     token,
+		ExpiresIn: int(pinTokenTTL.Seconds()),
+	}, nil
+}
+
+// SetPin mengatur PIN milik user sendiri. Password tetap diminta supaya PIN
+// tidak bisa diganti hanya dengan token yang sedang aktif
+func (srvc *AuthService) SetPin(userID int, password, pin string) error {
+	user, err := srvc.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.CheckPassword(user.Password, password); err != nil {
+		return ErrWrongPassword
+	}
+
+	hashed, err := utils.HashPassword(pin)
+	if err != nil {
+		return err
+	}
+
+	return srvc.repo.UpdatePin(userID, hashed)
+}
+
+func (srvc *AuthService) GetLoginAttempts(filter model.LoginAttemptFilter) ([]model.LoginAttempt, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return srvc.repo.GetLoginAttempts(filter)
+}
+
+func (srvc *AuthService) registerFailedLogin(userID int) error {
+	attempts, err := srvc.repo.IncrementFailedLogins(userID)
+	if err != nil {
+		return err
+	}
+
+	if attempts%maxFailedLogins != 0 {
+		return nil
+	}
+
+	lockDuration := baseLockDuration
+	for i := 1; i < attempts/maxFailedLogins && lockDuration < maxLockDuration; i++ {
+		lockDuration *= 2
+	}
+	if lockDuration > maxLockDuration {
+		lockDuration = maxLockDuration
+	}
+
+	return srvc.repo.LockUser(userID, time.Now().Add(lockDuration))
+}
+
+// checkIPThrottle menolak login dari IP yang sudah terlalu sering gagal
+func (srvc *AuthService) checkIPThrottle(identifier, ip string) error {
+	failedByIP, err := srvc.repo.CountFailedLoginsByIP(ip, time.Now().Add(-ipAttemptWindow))
+	if err != nil {
+		return err
+	}
+	if failedByIP >= maxFailedLoginsPerIP {
+		srvc.recordLoginAttempt(identifier, ip, false, "ip_rate_limited")
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+// recordLoginAttempt menyimpan audit login. Gagal menyimpan audit tidak boleh
+// menggagalkan proses login, jadi error hanya di-log
+func (srvc *AuthService) recordLoginAttempt(email, ip string, success bool, reason string) {
+	err := srvc.repo.CreateLoginAttempt(&model.LoginAttempt{
+		Email:     email,
+		IPAddress: ip,
+		Success:   success,
+		Reason:    reason,
+	})
+	if err != nil {
+		log.Println("failed to record login attempt:", err)
+	}
+}
+
+// Refresh menukar refresh token dengan access token baru. Refresh token lama langsung
+// di-revoke (rotation). Jika token yang sudah di-revoke dipakai lagi, semua sesi user
+// tersebut ikut di-revoke karena kemungkinan token sudah bocor
+func (srvc *AuthService) Refresh(refreshToken string) (*model.LoginResponse, error) {
+	stored, err := srvc.repo.GetRefreshToken(utils.HashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		if err := srvc.repo.RevokeAllRefreshTokens(stored.UserID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	revoked, err := srvc.repo.RevokeRefreshToken(stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := srvc.repo.GetUserByID(stored.UserID)
+	if err != nil || !user.Active {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Role yang baru diwajibkan 2FA harus login ulang supaya diarahkan ke setup 2FA
+	if srvc.twoFactorRequired(user.Role) && !user.TwoFactorEnabled {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return srvc.issueTokens(user)
+}
+
+// Logout me-revoke refresh token yang diberikan, atau semua sesi milik user jika all = true
+func (srvc *AuthService) Logout(refreshToken string, all bool) error {
+	stored, err := srvc.repo.GetRefreshToken(utils.HashToken(refreshToken))
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+
+	if all {
+		return srvc.repo.RevokeAllRefreshTokens(stored.UserID)
+	}
+
+	_, err = srvc.repo.RevokeRefreshToken(stored.ID)
+	return err
+}
+
+// ChangePassword mengganti password user yang sedang login. Semua sesi lain ikut di-revoke
+func (srvc *AuthService) ChangePassword(userID int, oldPassword, newPassword string) error {
+	user, err := srvc.repo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.CheckPassword(user.Password, oldPassword); err != nil {
+		return ErrWrongPassword
+	}
+
+	return srvc.setPassword(user.ID, newPassword)
+}
+
+// ForgotPassword membuat token reset sekali pakai dan mengirimkannya lewat notifier.
+// Email yang tidak terdaftar tidak menghasilkan error supaya tidak bisa dipakai
+// untuk mengecek email mana saja yang terdaftar
+func (srvc *AuthService) ForgotPassword(email string) error {
+	user, err := srvc.repo.GetUserByEmail(email)
+	if err != nil {
+		return nil
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(passwordResetTTL)
+	err = srvc.repo.CreatePasswordReset(&model.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return err
 	}
 
-	token, err := utils.GenerateToken(user.ID, srvc.secret)
+	return srvc.notifier.Send(notifier.Message{
+		To:      user.Email,
+		Subject: "Reset password Kasir API",
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nGunakan token berikut untuk reset password kamu: %s\nToken berlaku sampai %s dan hanya bisa dipakai sekali.",
+			user.Name, token, expiresAt.Format("2006-01-02 15:04"),
+		),
+	})
+}
+
+// ResetPassword mengganti password memakai token dari ForgotPassword
+func (srvc *AuthService) ResetPassword(token, newPassword string) error {
+	reset, err := srvc.repo.GetPasswordReset(utils.HashToken(token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	if reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	used, err := srvc.repo.UsePasswordResets(reset.UserID, reset.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+
+	return srvc.setPassword(reset.UserID, newPassword)
+}
+
+func (srvc *AuthService) setPassword(userID int, password string) error {
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := srvc.repo.UpdatePassword(userID, hashed); err != nil {
+		return err
+	}
+
+	return srvc.repo.RevokeAllRefreshTokens(userID)
+}
+
+func (srvc *AuthService) issueTokens(user *model.User) (*model.LoginResponse, error) {
+	token, err := utils.GenerateToken(utils.Claims{UserID: user.ID, Role: user.Role}, srvc.secret, accessTokenTTL)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = srvc.repo.CreateRefreshToken(&model.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return user, token, nil
+	user.Password = ""
+	return &model.LoginResponse{
+		User:         user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	}, nil
 }
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"errors"
+
 	"kasir-api/model"
 	"kasir-api/repositories"
 )
 
+// ErrInvalidDeleteMode dikembalikan saat mode hapus kategori tidak dikenal atau reassign tanpa target_id
+var ErrInvalidDeleteMode = errors.New("mode must be restrict, reassign (with target_id) or archive")
+
 type CategoryService struct {
 	repo *repositories.CategoryRepository
 }
@@ -17,6 +22,10 @@ func (s *CategoryService) GetAll() ([]model.Category, error) {
 	return s.repo.GetAll()
 }
 
+func (s *CategoryService) GetTree() ([]model.CategoryNode, error) {
+	return s.repo.GetTree()
+}
+
 func (s *CategoryService) Create(data *model.Category) error {
 	return s.repo.Create(data)
 }
@@ -29,6 +38,23 @@ func (s *CategoryService) Update(category *model.Category) error {
 	return s.repo.Update(category)
 }
 
-func (s *CategoryService) Delete(id int) error {
-	return s.repo.Delete(id)
+func (s *CategoryService) Move(id int, parentID *int) error {
+	return s.repo.Move(id, parentID)
+}
+
+// Delete menghapus kategori sesuai mode. Mode kosong berarti restrict
+func (s *CategoryService) Delete(id int, req model.DeleteCategoryRequest) error {
+	switch req.Mode {
+	case "", model.CategoryDeleteRestrict:
+		return s.repo.Delete(id, nil)
+	case model.CategoryDeleteReassign:
+		if req.TargetID == 0 {
+			return ErrInvalidDeleteMode
+		}
+		return s.repo.Delete(id, &req.TargetID)
+	case model.CategoryDeleteArchive:
+		return s.repo.Archive(id)
+	default:
+		return ErrInvalidDeleteMode
+	}
 }
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+)
+
+// ErrInvalidModifierGroup dikembalikan saat batas pilihan group tidak masuk akal
+var ErrInvalidModifierGroup = errors.New("invalid modifier group: optional groups need min_select 0, and min_select <= max_select <= number of modifiers")
+
+type ModifierService struct {
+	repo *repositories.ModifierRepository
+}
+
+func NewModifierService(repo *repositories.ModifierRepository) *ModifierService {
+	return &ModifierService{repo: repo}
+}
+
+func (s *ModifierService) GetByProduct(productID int) ([]model.ModifierGroup, error) {
+	return s.repo.GetByProduct(productID)
+}
+
+func (s *ModifierService) Create(group *model.ModifierGroup) error {
+	if err := normalizeModifierGroup(group); err != nil {
+		return err
+	}
+	return s.repo.Create(group)
+}
+
+func (s *ModifierService) Update(group *model.ModifierGroup) error {
+	if err := normalizeModifierGroup(group); err != nil {
+		return err
+	}
+	return s.repo.Update(group)
+}
+
+func (s *ModifierService) Delete(productID, groupID int) error {
+	return s.repo.Delete(productID, groupID)
+}
+
+// normalizeModifierGroup mengisi default batas pilihan: max_select 0 berarti 1,
+// dan group required minimal harus memilih satu modifier
+func normalizeModifierGroup(g *model.ModifierGroup) error {
+	g.Name = strings.TrimSpace(g.Name)
+	for i := range g.Modifiers {
+		g.Modifiers[i].Name = strings.TrimSpace(g.Modifiers[i].Name)
+	}
+
+	if g.MaxSelect == 0 {
+		g.MaxSelect = 1
+	}
+	if g.Required && g.MinSelect == 0 {
+		g.MinSelect = 1
+	}
+
+	if !g.Required && g.MinSelect > 0 {
+		return ErrInvalidModifierGroup
+	}
+	if g.MinSelect > g.MaxSelect || g.MaxSelect > len(g.Modifiers) {
+		return ErrInvalidModifierGroup
+	}
+
+	return nil
+}
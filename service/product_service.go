@@ -1,8 +1,22 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"kasir-api/model"
 	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+var (
+	// ErrInvalidBarcode dikembalikan saat barcode produk tidak valid
+	ErrInvalidBarcode = errors.New("invalid barcode")
+	// ErrInvalidMinStock dikembalikan saat batas minimum stok bernilai negatif
+	ErrInvalidMinStock = errors.New("min_stock must not be negative")
+	// ErrInvalidCostPrice dikembalikan saat harga pokok bernilai negatif
+	ErrInvalidCostPrice = errors.New("cost_price must not be negative")
 )
 
 type ProductService struct {
@@ -13,12 +27,36 @@ func NewProductService(repo *repositories.ProductRepository) *ProductService {
 	return &ProductService{repo: repo}
 }
 
-func (s *ProductService) GetAll(name string) ([]model.Product, error) {
-	return s.repo.GetAll(name)
+const (
+	defaultProductLimit = 20
+	maxProductLimit     = 100
+)
+
+func (s *ProductService) GetAll(filter model.ProductFilter) ([]model.Product, *model.Pagination, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultProductLimit
+	}
+	if filter.Limit > maxProductLimit {
+		filter.Limit = maxProductLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	products, total, err := s.repo.GetAll(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pagination := model.NewPagination(total, filter.Limit, filter.Offset)
+	return products, &pagination, nil
 }
 
-func (s *ProductService) Create(data *model.Product) error {
-	return s.repo.Create(data)
+func (s *ProductService) Create(data *model.Product, userID *int) error {
+	if err := validateProduct(data); err != nil {
+		return err
+	}
+	return s.repo.Create(data, userID)
 }
 
 func (s *ProductService) GetByID(id int) (*model.Product, error) {
@@ -29,10 +67,93 @@ func (s *ProductService) GetByIDWithCategory(id int) (*model.ProductWithCategory
 	return s.repo.GetByIDWithCategory(id)
 }
 
-func (s *ProductService) Update(product *model.Product) error {
-	return s.repo.Update(product)
+// Update mengubah data produk dan mengembalikan produk terkini. Field yang
+// tidak dikirim di req tetap memakai nilai lama
+func (s *ProductService) Update(id int, req model.UpdateProductRequest) (*model.Product, error) {
+	if req.MinStock != nil && *req.MinStock < 0 {
+		return nil, ErrInvalidMinStock
+	}
+
+	product := &model.Product{
+		ID:         id,
+		Name:       req.Name,
+		Price:      req.Price,
+		BaseUnit:   req.BaseUnit,
+		CategoryID: req.CategoryID,
+		SKU:        req.SKU,
+		Barcodes:   req.Barcodes,
+	}
+	if err := normalizeProductCodes(product); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(product, req.MinStock, req.TrackExpiry); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// GetByBarcode mencari produk dari hasil scan barcode
+func (s *ProductService) GetByBarcode(code string) (*model.Product, error) {
+	return s.repo.GetByBarcode(strings.TrimSpace(code))
+}
+
+// GetLowStock mengambil produk yang perlu segera di-reorder
+func (s *ProductService) GetLowStock() ([]model.Product, error) {
+	return s.repo.GetLowStock()
+}
+
+// GetLots mengambil lot produk track_expiry yang masih punya sisa stok
+func (s *ProductService) GetLots(productID int) ([]model.ProductLot, error) {
+	return s.repo.GetLots(productID)
+}
+
+// GetExpiring mengambil lot yang kedaluwarsa dalam days hari ke depan (atau sudah kedaluwarsa)
+func (s *ProductService) GetExpiring(days int) ([]model.ProductLot, error) {
+	return s.repo.GetExpiring(days)
+}
+
+func validateProduct(product *model.Product) error {
+	if product.MinStock < 0 {
+		return ErrInvalidMinStock
+	}
+	if product.CostPrice < 0 {
+		return ErrInvalidCostPrice
+	}
+	return normalizeProductCodes(product)
+}
+
+// normalizeProductCodes merapikan SKU, membuang barcode duplikat, dan
+// memvalidasi check digit setiap barcode
+func normalizeProductCodes(product *model.Product) error {
+	product.SKU = strings.TrimSpace(product.SKU)
+
+	if product.Barcodes == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(product.Barcodes))
+	barcodes := make([]string, 0, len(product.Barcodes))
+	for _, code := range product.Barcodes {
+		code = strings.TrimSpace(code)
+		if seen[code] {
+			continue
+		}
+		if err := utils.ValidateBarcode(code); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidBarcode, err)
+		}
+		seen[code] = true
+		barcodes = append(barcodes, code)
+	}
+	product.Barcodes = barcodes
+
+	return nil
 }
 
 func (s *ProductService) Delete(id int) error {
 	return s.repo.Delete(id)
 }
+
+func (s *ProductService) Restore(id int) error {
+	return s.repo.Restore(id)
+}
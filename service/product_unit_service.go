@@ -0,0 +1,34 @@
+package service
+
+import (
+	"strings"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+)
+
+type ProductUnitService struct {
+	repo *repositories.ProductUnitRepository
+}
+
+func NewProductUnitService(repo *repositories.ProductUnitRepository) *ProductUnitService {
+	return &ProductUnitService{repo: repo}
+}
+
+func (s *ProductUnitService) GetByProduct(productID int) ([]model.ProductUnit, error) {
+	return s.repo.GetByProduct(productID)
+}
+
+func (s *ProductUnitService) Create(unit *model.ProductUnit) error {
+	unit.Name = strings.TrimSpace(unit.Name)
+	return s.repo.Create(unit)
+}
+
+func (s *ProductUnitService) Update(unit *model.ProductUnit) error {
+	unit.Name = strings.TrimSpace(unit.Name)
+	return s.repo.Update(unit)
+}
+
+func (s *ProductUnitService) Delete(productID, unitID int) error {
+	return s.repo.Delete(productID, unitID)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"strings"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+)
+
+type ProductVariantService struct {
+	repo *repositories.ProductVariantRepository
+}
+
+func NewProductVariantService(repo *repositories.ProductVariantRepository) *ProductVariantService {
+	return &ProductVariantService{repo: repo}
+}
+
+func (s *ProductVariantService) GetByProduct(productID int) ([]model.ProductVariant, error) {
+	return s.repo.GetByProduct(productID)
+}
+
+func (s *ProductVariantService) Create(variant *model.ProductVariant, userID *int) error {
+	variant.Name = strings.TrimSpace(variant.Name)
+	variant.SKU = strings.TrimSpace(variant.SKU)
+	return s.repo.Create(variant, userID)
+}
+
+func (s *ProductVariantService) Update(variant *model.ProductVariant) error {
+	variant.Name = strings.TrimSpace(variant.Name)
+	variant.SKU = strings.TrimSpace(variant.SKU)
+	return s.repo.Update(variant)
+}
+
+func (s *ProductVariantService) Delete(productID, variantID int) error {
+	return s.repo.Delete(productID, variantID)
+}
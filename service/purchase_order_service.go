@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+)
+
+// ErrDuplicateOrderItem dikembalikan saat produk (atau varian) yang sama muncul lebih dari sekali dalam satu request
+var ErrDuplicateOrderItem = errors.New("each product or variant may only appear once per request")
+
+type PurchaseOrderService struct {
+	repo *repositories.PurchaseOrderRepository
+}
+
+func NewPurchaseOrderService(repo *repositories.PurchaseOrderRepository) *PurchaseOrderService {
+	return &PurchaseOrderService{repo: repo}
+}
+
+func (s *PurchaseOrderService) GetAll(filter model.PurchaseOrderFilter) ([]model.PurchaseOrder, error) {
+	return s.repo.GetAll(filter)
+}
+
+func (s *PurchaseOrderService) GetByID(id int) (*model.PurchaseOrder, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *PurchaseOrderService) Create(req model.CreatePurchaseOrderRequest, userID *int) (*model.PurchaseOrder, error) {
+	seen := make(map[[2]int]bool, len(req.Items))
+	for _, item := range req.Items {
+		line := [2]int{item.ProductID, item.VariantID}
+		if seen[line] {
+			return nil, ErrDuplicateOrderItem
+		}
+		seen[line] = true
+	}
+
+	po := model.PurchaseOrder{
+		SupplierID: req.SupplierID,
+		Note:       req.Note,
+		CreatedBy:  userID,
+	}
+	if err := s.repo.Create(&po, req.Items); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(po.ID)
+}
+
+// Send menandai PO draft sudah dikirim ke supplier, sehingga barangnya bisa diterima
+func (s *PurchaseOrderService) Send(id int) (*model.PurchaseOrder, error) {
+	err := s.repo.UpdateStatus(id, []string{model.PurchaseOrderDraft}, model.PurchaseOrderSent)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Cancel membatalkan PO yang belum selesai diterima. Barang yang sudah
+// diterima sebagian tetap tercatat di stok
+func (s *PurchaseOrderService) Cancel(id int) (*model.PurchaseOrder, error) {
+	from := []string{model.PurchaseOrderDraft, model.PurchaseOrderSent, model.PurchaseOrderPartiallyReceived}
+	if err := s.repo.UpdateStatus(id, from, model.PurchaseOrderCancelled); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+func (s *PurchaseOrderService) Receive(id int, req model.ReceivePurchaseOrderRequest, userID *int) (*model.PurchaseOrder, error) {
+	seen := make(map[[2]int]bool, len(req.Items))
+	for _, item := range req.Items {
+		line := [2]int{item.ProductID, item.VariantID}
+		if seen[line] {
+			return nil, ErrDuplicateOrderItem
+		}
+		seen[line] = true
+	}
+
+	if err := s.repo.Receive(id, req, userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"errors"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+)
+
+// ErrDuplicateIngredient dikembalikan saat bahan yang sama muncul lebih dari sekali dalam satu resep
+var ErrDuplicateIngredient = errors.New("each ingredient may only appear once per recipe")
+
+type RecipeService struct {
+	repo *repositories.RecipeRepository
+}
+
+func NewRecipeService(repo *repositories.RecipeRepository) *RecipeService {
+	return &RecipeService{repo: repo}
+}
+
+func (s *RecipeService) GetByProduct(productID int) (*model.Recipe, error) {
+	return s.repo.GetByProduct(productID)
+}
+
+// Set mengganti resep produk lalu mengembalikan resep terbaru beserta HPP-nya
+func (s *RecipeService) Set(productID int, req model.SetRecipeRequest) (*model.Recipe, error) {
+	seen := make(map[int]bool, len(req.Ingredients))
+	for _, ing := range req.Ingredients {
+		if seen[ing.IngredientID] {
+			return nil, ErrDuplicateIngredient
+		}
+		seen[ing.IngredientID] = true
+	}
+
+	if err := s.repo.Replace(productID, req.Ingredients); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByProduct(productID)
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"kasir-api/model"
+	"kasir-api/repositories"
+)
+
+type StockOpnameService struct {
+	repo *repositories.StockOpnameRepository
+}
+
+func NewStockOpnameService(repo *repositories.StockOpnameRepository) *StockOpnameService {
+	return &StockOpnameService{repo: repo}
+}
+
+func (s *StockOpnameService) Start(note string, userID *int) (*model.StockOpname, error) {
+	opname := model.StockOpname{Note: note, StartedBy: userID}
+	if err := s.repo.Create(&opname); err != nil {
+		return nil, err
+	}
+	return &opname, nil
+}
+
+func (s *StockOpnameService) GetAll() ([]model.StockOpname, error) {
+	return s.repo.GetAll()
+}
+
+// GetDetail mengembalikan sesi opname beserta item, selisih, dan ringkasan nilai shrinkage
+func (s *StockOpnameService) GetDetail(id int) (*model.StockOpnameDetail, error) {
+	opname, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetItems(id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := model.StockOpnameDetail{StockOpname: *opname, Items: items}
+	detail.Summary.TotalItems = len(items)
+	for _, item := range items {
+		switch {
+		case item.Variance < 0:
+			detail.Summary.ItemsWithVariance++
+			detail.Summary.ShrinkageQty += -item.Variance
+			detail.Summary.ShrinkageValue += -item.VarianceValue
+		case item.Variance > 0:
+			detail.Summary.ItemsWithVariance++
+			detail.Summary.SurplusQty += item.Variance
+			detail.Summary.SurplusValue += item.VarianceValue
+		}
+		detail.Summary.NetVarianceValue += item.VarianceValue
+	}
+
+	return &detail, nil
+}
+
+func (s *StockOpnameService) SubmitCounts(id int, counts []model.StockCount, userID *int) (*model.StockOpnameDetail, error) {
+	if err := s.repo.SubmitCounts(id, counts, userID); err != nil {
+		return nil, err
+	}
+	return s.GetDetail(id)
+}
+
+func (s *StockOpnameService) Finalize(id int, userID *int) (*model.StockOpnameDetail, error) {
+	if err := s.repo.Finalize(id, userID); err != nil {
+		return nil, err
+	}
+	return s.GetDetail(id)
+}
+
+func (s *StockOpnameService) Cancel(id int, userID *int) error {
+	return s.repo.Cancel(id, userID)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+	"math"
+
+	"kasir-api/model"
+	"kasir-api/repositories"
+)
+
+// ErrInvalidMovement dikembalikan saat quantity tidak sesuai dengan jenis pergerakan stok
+var ErrInvalidMovement = errors.New("quantity must be positive for restock, return and waste, and non-zero for adjustment")
+
+const (
+	defaultMovementLimit = 50
+	maxMovementLimit     = 200
+)
+
+type StockService struct {
+	repo *repositories.StockRepository
+}
+
+func NewStockService(repo *repositories.StockRepository) *StockService {
+	return &StockService{repo: repo}
+}
+
+// CreateMovement mencatat pergerakan stok manual. Quantity pada request diubah
+// menjadi selisih bertanda dalam satuan dasar sesuai jenisnya sebelum disimpan
+// ke ledger
+func (s *StockService) CreateMovement(productID int, req model.CreateStockMovementRequest, userID *int) (*model.StockMovement, error) {
+	quantity, factor, err := s.repo.ToBaseQuantity(productID, req.Unit, req.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Type {
+	case model.MovementRestock, model.MovementReturn:
+		if quantity <= 0 {
+			return nil, ErrInvalidMovement
+		}
+	case model.MovementWaste:
+		if quantity <= 0 {
+			return nil, ErrInvalidMovement
+		}
+		quantity = -quantity
+	case model.MovementAdjustment:
+		if quantity == 0 {
+			return nil, ErrInvalidMovement
+		}
+	default:
+		return nil, ErrInvalidMovement
+	}
+
+	movement := model.StockMovement{
+		ProductID: productID,
+		VariantID: req.VariantID,
+		Type:      req.Type,
+		Quantity:  quantity,
+		Reason:    req.Reason,
+		Reference: req.Reference,
+		UserID:    userID,
+	}
+	// Harga beli hanya relevan untuk barang masuk dari supplier, dan tanggal
+	// kedaluwarsa untuk barang masuk yang menjadi lot baru
+	if req.Type == model.MovementRestock && req.UnitCost != nil {
+		unitCost := int(math.Round(float64(*req.UnitCost) / float64(factor)))
+		movement.UnitCost = &unitCost
+	}
+	if quantity > 0 {
+		movement.ExpiryDate = req.ExpiryDate
+	}
+	if err := s.repo.CreateMovement(&movement); err != nil {
+		return nil, err
+	}
+
+	return &movement, nil
+}
+
+func (s *StockService) GetByProduct(productID, limit, offset int) ([]model.StockMovement, *model.Pagination, error) {
+	if limit <= 0 {
+		limit = defaultMovementLimit
+	}
+	if limit > maxMovementLimit {
+		limit = maxMovementLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	movements, total, err := s.repo.GetByProduct(productID, limit, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pagination := model.NewPagination(total, limit, offset)
+	return movements, &pagination, nil
+}
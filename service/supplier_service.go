@@ -0,0 +1,34 @@
+package service
+
+import (
+	"kasir-api/model"
+	"kasir-api/repositories"
+)
+
+type SupplierService struct {
+	repo *repositories.SupplierRepository
+}
+
+func NewSupplierService(repo *repositories.SupplierRepository) *SupplierService {
+	return &SupplierService{repo: repo}
+}
+
+func (s *SupplierService) GetAll() ([]model.Supplier, error) {
+	return s.repo.GetAll()
+}
+
+func (s *SupplierService) Create(supplier *model.Supplier) error {
+	return s.repo.Create(supplier)
+}
+
+func (s *SupplierService) GetByID(id int) (*model.Supplier, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *SupplierService) Update(supplier *model.Supplier) error {
+	return s.repo.Update(supplier)
+}
+
+func (s *SupplierService) Delete(id int) error {
+	return s.repo.Delete(id)
+}
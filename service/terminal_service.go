@@ -0,0 +1,42 @@
+package service
+
+import (
+	"kasir-api/model"
+	"kasir-api/repositories"
+	"kasir-api/utils"
+)
+
+type TerminalService struct {
+	repo *repositories.TerminalRepository
+}
+
+func NewTerminalService(repo *repositories.TerminalRepository) *TerminalService {
+	return &TerminalService{repo: repo}
+}
+
+func (s *TerminalService) GetAll() ([]model.Terminal, error) {
+	return s.repo.GetAll()
+}
+
+// Create mendaftarkan terminal baru dan mengembalikan key-nya. Key disimpan di
+// perangkat kasir dan dikirim lewat header X-Terminal-Key saat PIN login
+func (s *TerminalService) Create(name string) (*model.CreateTerminalResponse, error) {
+	key, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	terminal := model.Terminal{
+		Name:    name,
+		KeyHash: utils.HashToken(key),
+	}
+	if err := s.repo.Create(&terminal); err != nil {
+		return nil, err
+	}
+
+	return &model.CreateTerminalResponse{Terminal: &terminal, Key: key}, nil
+}
+
+func (s *TerminalService) SetActive(id int, active bool) error {
+	return s.repo.SetActive(id, active)
+}
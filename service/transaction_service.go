@@ -1,26 +1,129 @@
 package service
 
 import (
+	"fmt"
+	"log"
+	"math"
+
 	"kasir-api/model"
+	"kasir-api/notifier"
 	"kasir-api/repositories"
 )
 
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo          *repositories.TransactionRepository
+	stockNotifier notifier.Notifier
+}
+
+func NewTransactionService(repo *repositories.TransactionRepository, stockNotifier notifier.Notifier) *TransactionService {
+	return &TransactionService{repo: repo, stockNotifier: stockNotifier}
+}
+
+func (s *TransactionService) Checkout(items []model.CheckoutItem, userID *int) (*model.Transaction, error) {
+	transaction, err := s.repo.CreateTransaction(items, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(transaction.LowStockAlerts) > 0 {
+		// Dikirim di background supaya checkout tidak menunggu notifier (misalnya webhook yang lambat)
+		go s.notifyLowStock(transaction.LowStockAlerts)
+	}
+
+	return transaction, nil
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
-	return &TransactionService{repo: repo}
+func (s *TransactionService) notifyLowStock(alerts []model.LowStockAlert) {
+	for _, alert := range alerts {
+		err := s.stockNotifier.Send(notifier.Message{
+			Subject: fmt.Sprintf("Stok menipis: %s", alert.ProductName),
+			Body:    fmt.Sprintf("Stok %s tinggal %d (minimum %d). Segera lakukan reorder.", alert.ProductName, alert.Stock, alert.MinStock),
+			Event:   "low_stock",
+			Data:    alert,
+		})
+		if err != nil {
+			log.Printf("failed to send low stock alert for product %d: %v", alert.ProductID, err)
+		}
+	}
 }
 
-func (s *TransactionService) Checkout(items []model.CheckoutItem) (*model.Transaction, error) {
-	return s.repo.CreateTransaction(items)
+func (s *TransactionService) GetByID(id int) (*model.Transaction, error) {
+	return s.repo.GetByID(id)
 }
 
 func (s *TransactionService) GetTodaySummary() (*model.SalesSummary, error) {
-	return s.repo.GetTodaySummary()
+	return fillGrossProfit(s.repo.GetTodaySummary())
 }
 
 func (s *TransactionService) GetSummaryByRange(startDate, endDate string) (*model.SalesSummary, error) {
-	return s.repo.GetSummaryByRange(startDate, endDate)
+	return fillGrossProfit(s.repo.GetSummaryByRange(startDate, endDate))
+}
+
+func fillGrossProfit(summary *model.SalesSummary, err error) (*model.SalesSummary, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	summary.GrossProfit = summary.TotalRevenue - summary.TotalCOGS
+	summary.GrossMargin = grossMargin(summary.TotalRevenue, summary.GrossProfit)
+	return summary, nil
+}
+
+// GetProfitReport menghitung laba kotor dan margin total, per produk, per varian,
+// dan per kategori, ditambah pendapatan per modifier
+func (s *TransactionService) GetProfitReport(startDate, endDate string) (*model.ProfitReport, error) {
+	products, err := s.repo.GetProfitByProduct(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := s.repo.GetProfitByVariant(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.repo.GetProfitByCategory(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	modifiers, err := s.repo.GetProfitByModifier(startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := model.ProfitReport{
+		StartDate:  startDate,
+		EndDate:    endDate,
+		Products:   products,
+		Variants:   variants,
+		Categories: categories,
+		Modifiers:  modifiers,
+	}
+	for i := range report.Products {
+		line := &report.Products[i]
+		line.GrossProfit = line.Revenue - line.COGS
+		line.GrossMargin = grossMargin(line.Revenue, line.GrossProfit)
+
+		report.Revenue += line.Revenue
+		report.COGS += line.COGS
+	}
+	for _, lines := range [][]model.ProfitLine{report.Variants, report.Categories, report.Modifiers} {
+		for i := range lines {
+			lines[i].GrossProfit = lines[i].Revenue - lines[i].COGS
+			lines[i].GrossMargin = grossMargin(lines[i].Revenue, lines[i].GrossProfit)
+		}
+	}
+	report.GrossProfit = report.Revenue - report.COGS
+	report.GrossMargin = grossMargin(report.Revenue, report.GrossProfit)
+
+	return &report, nil
+}
+
+// grossMargin mengembalikan laba kotor sebagai persentase pendapatan, dibulatkan 2 desimal
+func grossMargin(revenue, profit int) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(profit)/float64(revenue)*10000) / 100
 }
@@ -1,10 +1,15 @@
 package service
 
 import (
+	"errors"
 	"kasir-api/model"
 	"kasir-api/repositories"
+	"kasir-api/utils"
 )
 
+// ErrForbidden dikembalikan saat admin mencoba mengelola akun owner
+var ErrForbidden = errors.New("you do not have permission to manage this user")
+
 type UserService struct {
 	repo *repositories.UserRepository
 }
@@ -20,14 +25,90 @@ func (srvc *UserService) GetAll() ([]model.User, error) {
 	return srvc.repo.GetAll()
 }
 
+// Create membuat user baru dengan role tertentu. Hanya owner yang boleh membuat akun owner
+func (srvc *UserService) Create(actorRole string, req model.CreateUserRequest) (*model.User, error) {
+	if !model.IsValidRole(req.Role) {
+		return nil, errors.New("invalid role")
+	}
+	if req.Role == model.RoleOwner && actorRole != model.RoleOwner {
+		return nil, ErrForbidden
+	}
+
+	hashed, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := model.User{
+		Name:     req.Name,
+		Email:    req.Email,
+		Role:     req.Role,
+		Password: hashed,
+	}
+
+	err = srvc.repo.Create(&user)
+	return &user, err
+}
+
 func (srvc *UserService) GetByID(id int) (*model.User, error) {
 	return srvc.repo.GetByID(id)
 }
 
-func (srvc *UserService) Update(user *model.User) error {
+// Update mengubah data user. actorRole adalah role user yang melakukan perubahan
+func (srvc *UserService) Update(actorRole string, user *model.User) error {
+	if err := srvc.checkManageable(actorRole, user.ID); err != nil {
+		return err
+	}
 	return srvc.repo.Update(user)
 }
 
-func (srvc *UserService) Delete(id int) error {
+func (srvc *UserService) UpdateRole(id int, role string) error {
+	if !model.IsValidRole(role) {
+		return errors.New("invalid role")
+	}
+	return srvc.repo.UpdateRole(id, role)
+}
+
+func (srvc *UserService) Unlock(actorRole string, id int) error {
+	if err := srvc.checkManageable(actorRole, id); err != nil {
+		return err
+	}
+	return srvc.repo.Unlock(id)
+}
+
+func (srvc *UserService) SetActive(actorRole string, id int, active bool) error {
+	if err := srvc.checkManageable(actorRole, id); err != nil {
+		return err
+	}
+	return srvc.repo.SetActive(id, active)
+}
+
+func (srvc *UserService) Delete(actorRole string, id int) error {
+	if err := srvc.checkManageable(actorRole, id); err != nil {
+		return err
+	}
 	return srvc.repo.Delete(id)
 }
+
+// IsActive mengecek apakah user masih ada dan aktif, dipakai middleware untuk
+// menolak access token milik user yang sudah dinonaktifkan atau dihapus
+func (srvc *UserService) IsActive(id int) (bool, error) {
+	return srvc.repo.IsActive(id)
+}
+
+// checkManageable memastikan hanya owner yang bisa mengubah atau menghapus akun owner
+func (srvc *UserService) checkManageable(actorRole string, targetID int) error {
+	if actorRole == model.RoleOwner {
+		return nil
+	}
+
+	target, err := srvc.repo.GetByID(targetID)
+	if err != nil {
+		return err
+	}
+	if target.Role == model.RoleOwner {
+		return ErrForbidden
+	}
+
+	return nil
+}
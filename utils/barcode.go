@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ValidateBarcode memvalidasi barcode EAN-8, UPC-A (12 digit), atau EAN-13
+// termasuk check digit-nya (algoritma GS1 modulo 10)
+func ValidateBarcode(code string) error {
+	switch len(code) {
+	case 8, 12, 13:
+	default:
+		return fmt.Errorf("barcode %q must be 8 (EAN-8), 12 (UPC-A) or 13 (EAN-13) digits", code)
+	}
+
+	sum := 0
+	for i := 0; i < len(code)-1; i++ {
+		c := code[i]
+		if c < '0' || c > '9' {
+			return fmt.Errorf("barcode %q must contain digits only", code)
+		}
+
+		// Bobot dihitung dari digit paling kanan (sebelum check digit): 3, 1, 3, 1, ...
+		digit := int(c - '0')
+		if (len(code)-1-i)%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	last := code[len(code)-1]
+	if last < '0' || last > '9' {
+		return fmt.Errorf("barcode %q must contain digits only", code)
+	}
+
+	if (10-sum%10)%10 != int(last-'0') {
+		return errors.New("invalid check digit for barcode " + code)
+	}
+
+	return nil
+}
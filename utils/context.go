@@ -0,0 +1,25 @@
+package utils
+
+import "context"
+
+type contextKey string
+
+const claimsKey contextKey = "claims"
+
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
+
+// UserIDFromContext mengembalikan ID user yang sudah terautentikasi oleh middleware
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	return claims.UserID, true
+}
@@ -1,17 +1,55 @@
 package utils
 
 import (
+	"errors"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(userID int, secret string) (string, error) {
-	claims := jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
-	}
+type Claims struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+	// Scopes membatasi permission token. Kosong berarti mengikuti semua permission role
+	Scopes []string `json:"scopes,omitempty"`
+	// TerminalID dan TerminalKeyHash mengikat token ke satu terminal kasir (PIN login)
+	TerminalID      int    `json:"terminal_id,omitempty"`
+	TerminalKeyHash string `json:"tkh,omitempty"`
+	// Purpose terisi untuk token khusus (misal challenge 2FA) yang tidak boleh dipakai sebagai access token
+	Purpose string `json:"purpose,omitempty"`
+	// APIKeyID terisi jika request diautentikasi memakai API key, bukan JWT
+	APIKeyID int `json:"-"`
+	jwt.RegisteredClaims
+}
+
+// HasScope mengecek apakah token boleh dipakai untuk permission tertentu
+func (c *Claims) HasScope(perm string) bool {
+	return len(c.Scopes) == 0 || slices.Contains(c.Scopes, perm)
+}
+
+func GenerateToken(claims Claims, secret string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
 }
+
+// ParseToken memvalidasi signature dan masa berlaku token, lalu mengembalikan claims-nya
+func ParseToken(tokenString, secret string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid || claims.UserID == 0 {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
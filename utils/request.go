@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// trustedProxies berisi jaringan reverse proxy yang boleh mengisi X-Forwarded-For
+var trustedProxies []*net.IPNet
+
+// SetTrustedProxies mengatur daftar reverse proxy tepercaya dari daftar IP atau
+// CIDR yang dipisah koma (mis. "10.0.0.0/8, 127.0.0.1"). Daftar kosong berarti
+// X-Forwarded-For selalu diabaikan
+func SetTrustedProxies(list string) error {
+	proxies := make([]*net.IPNet, 0)
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		proxies = append(proxies, network)
+	}
+
+	trustedProxies = proxies
+	return nil
+}
+
+func isTrustedProxy(ip net.IP) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP mengambil IP client dari koneksi langsung. X-Forwarded-For hanya
+// dibaca jika koneksi datang dari proxy tepercaya, dan yang dipakai adalah
+// alamat paling kanan yang bukan proxy tepercaya, karena entri di kirinya bisa
+// dipalsukan oleh client
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer := net.ParseIP(host)
+	if peer == nil || !isTrustedProxy(peer) {
+		return host
+	}
+
+	hops := make([]string, 0)
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			// Entri yang tidak valid berarti rantai di kirinya tidak bisa dipercaya
+			break
+		}
+		if !isTrustedProxy(ip) {
+			return ip.String()
+		}
+	}
+
+	return host
+}